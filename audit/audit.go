@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"gorm.io/gorm"
+)
+
+// ชนิดของการกระทำที่บันทึก
+const (
+	ActionRegister       = "user.register"
+	ActionUserCreate     = "user.create"
+	ActionLogin          = "user.login"
+	ActionLoginFailed    = "user.login_failed"
+	ActionPasswordChange = "user.password_change"
+	ActionPasswordReset  = "user.password_reset"
+	ActionUserUpdate     = "user.update"
+	ActionUserDelete     = "user.delete"
+	ActionUserRestore    = "user.restore"
+	ActionRoleChange     = "user.role_change"
+	ActionUserLock       = "user.lock"
+	ActionUserUnlock     = "user.unlock"
+	ActionPostUpdate     = "post.update"
+	ActionPostDelete     = "post.delete"
+	ActionPostRestore    = "post.restore"
+	ActionWebhookCreate  = "webhook.create"
+	ActionWebhookUpdate  = "webhook.update"
+	ActionWebhookDelete  = "webhook.delete"
+	ActionWebhookResend  = "webhook.redeliver"
+	ActionJobRetry       = "job.retry"
+	ActionJobDelete      = "job.delete"
+	ActionAuditExport    = "audit.export"
+)
+
+// ชนิดของสิ่งที่ถูกกระทำ
+const (
+	TargetUser    = "user"
+	TargetPost    = "post"
+	TargetWebhook = "webhook"
+	TargetJob     = "job"
+)
+
+// Actor ผู้กระทำและที่มาของ request
+type Actor struct {
+	UserID    uint
+	Username  string
+	IP        string
+	RequestID string
+}
+
+// Entry การกระทำหนึ่งครั้ง Before และ After เก็บเฉพาะ field ที่เกี่ยวข้อง ห้ามใส่รหัสผ่านหรือ secret
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetID   uint
+	Before     map[string]interface{}
+	After      map[string]interface{}
+}
+
+// บันทึกลง audit log ส่ง tx ของการเปลี่ยนแปลงเข้ามาเพื่อให้บันทึกเฉพาะเมื่อ commit สำเร็จ
+func Record(tx *gorm.DB, actor Actor, entry Entry) error {
+	before, err := encode(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := encode(entry.After)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.AuditLogs{
+		ActorID:    actor.UserID,
+		ActorName:  actor.Username,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     before,
+		After:      after,
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+		CreatedAt:  time.Now(),
+	}).Error
+}
+
+func encode(fields map[string]interface{}) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(fields)
+	return string(data), err
+}
+
+// เลือกเฉพาะ field ใน updates ที่ค่าต่างจาก current คืนค่าก่อนและหลังเปลี่ยน
+func Changes(current, updates map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	before := map[string]interface{}{}
+	after := map[string]interface{}{}
+	for field, value := range updates {
+		if reflect.DeepEqual(current[field], value) {
+			continue
+		}
+		before[field] = current[field]
+		after[field] = value
+	}
+	return before, after
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/NopparootSuree/go-social/fixtures"
+	"github.com/NopparootSuree/go-social/utils"
+)
+
+func migrate(_ *flag.FlagSet) func(a *app) error {
+	return func(a *app) error {
+		if err := utils.Migrate(a.db); err != nil {
+			return err
+		}
+		return a.out.print(map[string]bool{"migrated": true}, "database is up to date")
+	}
+}
+
+type seedResult struct {
+	fixtures.Summary
+	// ข้อมูลชุดนี้ถูกบันทึกไว้ตั้งแต่ครั้งก่อน จึงไม่ได้เพิ่มอะไร
+	AlreadyLoaded bool `json:"alreadyLoaded"`
+}
+
+// ข้อมูลตัวอย่างจาก fixtures ค่า seed เดียวกันได้ข้อมูลชุดเดียวกัน รันซ้ำด้วยค่าเดิมจะไม่เพิ่มข้อมูล
+func seed(flags *flag.FlagSet) func(a *app) error {
+	defaults := fixtures.DefaultConfig()
+	seed := flags.Int64("seed", defaults.Seed, "random seed; the same seed generates the same data")
+	users := flags.Int("users", defaults.Users, "number of users")
+	follows := flags.Int("follows", defaults.FollowsPerUser, "average follows per user")
+	posts := flags.Int("posts", defaults.PostsPerUser, "average posts per user")
+	comments := flags.Int("comments", defaults.CommentsPerPost, "average comments per public post")
+	reactions := flags.Int("reactions", defaults.ReactionsPerPost, "average reactions per public post")
+	password := flags.String("password", defaults.Password, "password of every generated user")
+	prefix := flags.String("prefix", "", "username prefix, to load another data set into the same database")
+	period := flags.Duration("period", defaults.Period, "spread timestamps over this period before now")
+	batch := flags.Int("batch", 500, "rows per insert statement")
+
+	return func(a *app) error {
+		data, err := fixtures.Generate(fixtures.Config{
+			Seed:             *seed,
+			Users:            *users,
+			FollowsPerUser:   *follows,
+			PostsPerUser:     *posts,
+			CommentsPerPost:  *comments,
+			ReactionsPerPost: *reactions,
+			Password:         *password,
+			Prefix:           *prefix,
+			Period:           *period,
+		})
+		if err != nil {
+			return err
+		}
+
+		result := seedResult{Summary: data.Summary()}
+		err = fixtures.Insert(a.db, data, *batch)
+		if errors.Is(err, fixtures.ErrLoaded) {
+			result.AlreadyLoaded = true
+			return a.out.print(result, "data set for seed %d is already loaded, nothing to do", *seed)
+		}
+		if err != nil {
+			return err
+		}
+
+		return a.out.print(result, "inserted %d users, %d follows, %d posts, %d comments and %d reactions (password %q)",
+			result.Users, result.Follows, result.Posts, result.Comments, result.Reactions, data.Password)
+	}
+}
@@ -0,0 +1,142 @@
+// คำสั่งสำหรับผู้ดูแลระบบ จัดการผู้ใช้และข้อมูลผ่านฐานข้อมูลโดยตรง
+//
+//	go run ./cmd/admin [--json] <command> [flags]
+//
+// ทุกการเปลี่ยนแปลงถูกบันทึกลง audit log ในชื่อ cli:<ผู้ใช้ของระบบปฏิบัติการ>
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/outbox"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/NopparootSuree/go-social/webhooks"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+// app สิ่งที่ทุกคำสั่งใช้ร่วมกัน
+type app struct {
+	db    *gorm.DB
+	out   output
+	actor audit.Actor
+}
+
+// command ประกาศ flag ของตัวเองแล้วคืนฟังก์ชันที่ทำงานหลังอ่าน flag และเชื่อมต่อฐานข้อมูลแล้ว
+type command struct {
+	usage string
+	setup func(flags *flag.FlagSet) func(a *app) error
+}
+
+var commands = map[string]command{
+	"create-user":    {"create a user account", createUser},
+	"reset-password": {"set a new password and revoke all sessions", resetPassword},
+	"set-role":       {"change the role of a user", setRole},
+	"lock":           {"lock an account and revoke all sessions", lockUser},
+	"unlock":         {"unlock an account", unlockUser},
+	"delete-post":    {"move a post to the trash", deletePost},
+	"restore-post":   {"restore a post from the trash", restorePost},
+	"migrate":        {"create or update database tables", migrate},
+	"seed":           {"insert demo data", seed},
+}
+
+func main() {
+	// ไม่มีไฟล์ .env ก็ใช้ env ของ shell ได้
+	godotenv.Load()
+
+	flags := flag.NewFlagSet("admin", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print results as JSON")
+	flags.Usage = func() { usage(flags.Output()) }
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	// อ่าน flag ก่อนเชื่อมต่อฐานข้อมูล เพื่อให้ -h ใช้ได้โดยไม่ต้องมีฐานข้อมูล
+	cmdFlags := flag.NewFlagSet("admin "+args[0], flag.ExitOnError)
+	run := cmd.setup(cmdFlags)
+	cmdFlags.Parse(args[1:])
+
+	out := output{json: *jsonOutput, w: os.Stdout}
+	db, err := utils.OpenDatabase()
+	if err != nil {
+		out.fail(err)
+	}
+
+	// event จาก CLI ต้องถึง webhook และ outbox เหมือนกับที่เกิดจาก API
+	events.Subscribe(webhooks.Enqueue)
+	events.Subscribe(outbox.Record)
+
+	a := &app{db: db, out: out, actor: cliActor()}
+	if err := run(a); err != nil {
+		out.fail(err)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: admin [--json] <command> [flags]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-16s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nrun 'admin <command> -h' for the flags of a command")
+}
+
+// ผู้กระทำใน audit log ใช้ request id เดียวกันทั้งการรันหนึ่งครั้ง
+func cliActor() audit.Actor {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	requestID, _ := utils.GenerateToken(8)
+	return audit.Actor{Username: "cli:" + name, RequestID: requestID}
+}
+
+// output พิมพ์ผลเป็นข้อความหรือ JSON ตาม --json
+type output struct {
+	json bool
+	w    io.Writer
+}
+
+// พิมพ์ผลลัพธ์ ถ้าเป็น JSON พิมพ์ value ไม่เช่นนั้นพิมพ์ข้อความตาม format
+func (o output) print(value interface{}, format string, args ...interface{}) error {
+	if o.json {
+		encoder := json.NewEncoder(o.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	_, err := fmt.Fprintf(o.w, format+"\n", args...)
+	return err
+}
+
+// พิมพ์ error ลง stderr แล้วจบด้วย exit code 1
+func (o output) fail(err error) {
+	if o.json {
+		json.NewEncoder(os.Stderr).Encode(map[string]string{"error": err.Error()})
+	} else {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+	os.Exit(1)
+}
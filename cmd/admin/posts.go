@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"gorm.io/gorm"
+)
+
+type postResult struct {
+	ID      uint   `json:"id"`
+	Title   string `json:"title"`
+	UserID  uint   `json:"userID"`
+	Deleted bool   `json:"deleted"`
+}
+
+func deletePost(flags *flag.FlagSet) func(a *app) error {
+	id := flags.Uint("id", 0, "post id")
+
+	return func(a *app) error {
+		if *id == 0 {
+			return errors.New("--id is required")
+		}
+
+		var post models.Posts
+		err := a.db.First(&post, *id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("post %d not found", *id)
+		}
+		if err != nil {
+			return err
+		}
+
+		// ย้ายลงถังขยะเหมือนการลบผ่าน API จะถูกลบถาวรเมื่อพ้นระยะเวลาเก็บ
+		err = a.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&post).Error; err != nil {
+				return err
+			}
+			err := audit.Record(tx, a.actor, audit.Entry{
+				Action:     audit.ActionPostDelete,
+				TargetType: audit.TargetPost,
+				TargetID:   post.PostID,
+				Before:     map[string]interface{}{"title": post.Title, "userID": post.UserID},
+			})
+			if err != nil {
+				return err
+			}
+			postID := post.PostID
+			return events.Publish(tx, events.Event{Type: events.PostDeleted, UserID: post.UserID, PostID: &postID})
+		})
+		if err != nil {
+			return err
+		}
+
+		result := postResult{ID: post.PostID, Title: post.Title, UserID: post.UserID, Deleted: true}
+		return a.out.print(result, "moved post %d to the trash", post.PostID)
+	}
+}
+
+func restorePost(flags *flag.FlagSet) func(a *app) error {
+	id := flags.Uint("id", 0, "post id")
+
+	return func(a *app) error {
+		if *id == 0 {
+			return errors.New("--id is required")
+		}
+
+		var post models.Posts
+		err := a.db.Unscoped().Where("deleted_at IS NOT NULL").First(&post, *id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("post %d is not in the trash", *id)
+		}
+		if err != nil {
+			return err
+		}
+
+		// โพสต์ของผู้ใช้ที่อยู่ในถังขยะต้องกู้คืนผ่านการกู้คืนผู้ใช้
+		var author int64
+		if err := a.db.Model(&models.Users{}).Where("id = ?", post.UserID).Count(&author).Error; err != nil {
+			return err
+		}
+		if author == 0 {
+			return errors.New("the author's account is deleted")
+		}
+
+		err = a.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Model(&post).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, a.actor, audit.Entry{
+				Action:     audit.ActionPostRestore,
+				TargetType: audit.TargetPost,
+				TargetID:   post.PostID,
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		result := postResult{ID: post.PostID, Title: post.Title, UserID: post.UserID}
+		return a.out.print(result, "restored post %d", post.PostID)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/mail"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"gorm.io/gorm"
+)
+
+type userResult struct {
+	ID       uint       `json:"id"`
+	Username string     `json:"username"`
+	Email    string     `json:"email"`
+	Role     string     `json:"role"`
+	LockedAt *time.Time `json:"lockedAt"`
+	// มีค่าเฉพาะเมื่อ CLI สุ่มรหัสผ่านให้
+	Password string `json:"password,omitempty"`
+	// จำนวน session ที่ถูกยกเลิก
+	RevokedSessions int64 `json:"revokedSessions,omitempty"`
+}
+
+func newUserResult(user models.Users) userResult {
+	return userResult{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		LockedAt: user.LockedAt,
+	}
+}
+
+func validRole(role string) bool {
+	return role == models.RoleUser || role == models.RoleAdmin
+}
+
+// หาผู้ใช้ที่ยังไม่ถูกลบจาก username
+func findUser(db *gorm.DB, username string) (models.Users, error) {
+	var user models.Users
+	if username == "" {
+		return user, errors.New("--username is required")
+	}
+	err := db.Where("username = ?", username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, fmt.Errorf("user %q not found", username)
+	}
+	return user, err
+}
+
+// ตรวจรหัสผ่านตาม policy เดียวกับ API ถ้าไม่ได้ระบุจะสุ่มให้ คืนรหัสผ่านที่สุ่มไว้เพื่อแสดงผล
+func choosePassword(password, username string) (hashed, generated string, err error) {
+	if password == "" {
+		if generated, err = utils.GenerateToken(12); err != nil {
+			return "", "", err
+		}
+		password = generated
+	} else {
+		policy, err := utils.NewPasswordPolicy()
+		if err != nil {
+			return "", "", err
+		}
+		if err := policy.Validate(password, username); err != nil {
+			return "", "", err
+		}
+	}
+	hashed, err = utils.HashPassword(password)
+	return hashed, generated, err
+}
+
+// ยกเลิกทุก session ของผู้ใช้ token ที่ออกไปแล้วจะใช้ไม่ได้ทันที
+func revokeSessions(tx *gorm.DB, userID uint) (int64, error) {
+	result := tx.Model(&models.Sessions{}).
+		Where("userID = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func createUser(flags *flag.FlagSet) func(a *app) error {
+	username := flags.String("username", "", "username, at least 6 characters")
+	email := flags.String("email", "", "email address")
+	fullname := flags.String("fullname", "", "full name, at least 6 characters")
+	password := flags.String("password", "", "password; a random one is generated and printed when empty")
+	role := flags.String("role", models.RoleUser, "user or admin")
+
+	return func(a *app) error {
+		switch {
+		case utf8.RuneCountInString(*username) < 6:
+			return errors.New("--username must be at least 6 characters")
+		case utf8.RuneCountInString(*fullname) < 6:
+			return errors.New("--fullname must be at least 6 characters")
+		case !validRole(*role):
+			return fmt.Errorf("unknown role %q", *role)
+		}
+		if _, err := mail.ParseAddress(*email); err != nil {
+			return fmt.Errorf("invalid --email: %w", err)
+		}
+
+		hashed, generated, err := choosePassword(*password, *username)
+		if err != nil {
+			return err
+		}
+
+		// รวมผู้ใช้ในถังขยะด้วย เหมือนการสมัครผ่าน API
+		var existing int64
+		err = a.db.Unscoped().Model(&models.Users{}).
+			Where("username = ? OR email = ?", *username, *email).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("username or email already taken")
+		}
+
+		user := models.Users{
+			Username:       *username,
+			HashedPassword: hashed,
+			Fullname:       *fullname,
+			Email:          *email,
+			Role:           *role,
+			Version:        1,
+		}
+		err = a.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			err := audit.Record(tx, a.actor, audit.Entry{
+				Action:     audit.ActionUserCreate,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				After:      map[string]interface{}{"username": user.Username, "email": user.Email, "role": user.Role},
+			})
+			if err != nil {
+				return err
+			}
+			return events.Publish(tx, events.Event{Type: events.UserRegistered, ActorID: user.ID})
+		})
+		if err != nil {
+			return err
+		}
+
+		result := newUserResult(user)
+		result.Password = generated
+		if generated != "" {
+			return a.out.print(result, "created user %s (id %d) with password %s", user.Username, user.ID, generated)
+		}
+		return a.out.print(result, "created user %s (id %d)", user.Username, user.ID)
+	}
+}
+
+func resetPassword(flags *flag.FlagSet) func(a *app) error {
+	username := flags.String("username", "", "user to reset")
+	password := flags.String("password", "", "new password; a random one is generated and printed when empty")
+
+	return func(a *app) error {
+		user, err := findUser(a.db, *username)
+		if err != nil {
+			return err
+		}
+		hashed, generated, err := choosePassword(*password, user.Username)
+		if err != nil {
+			return err
+		}
+
+		var revoked int64
+		err = a.db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Model(&user).Update("hashedPassword", hashed).Error
+			if err != nil {
+				return err
+			}
+			if revoked, err = revokeSessions(tx, user.ID); err != nil {
+				return err
+			}
+			return audit.Record(tx, a.actor, audit.Entry{
+				Action:     audit.ActionPasswordReset,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				After:      map[string]interface{}{"revokedSessions": revoked},
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		result := newUserResult(user)
+		result.Password = generated
+		result.RevokedSessions = revoked
+		if generated != "" {
+			return a.out.print(result, "reset password of %s to %s, revoked %d sessions", user.Username, generated, revoked)
+		}
+		return a.out.print(result, "reset password of %s, revoked %d sessions", user.Username, revoked)
+	}
+}
+
+func setRole(flags *flag.FlagSet) func(a *app) error {
+	username := flags.String("username", "", "user to change")
+	role := flags.String("role", "", "user or admin")
+
+	return func(a *app) error {
+		if !validRole(*role) {
+			return fmt.Errorf("unknown role %q", *role)
+		}
+		user, err := findUser(a.db, *username)
+		if err != nil {
+			return err
+		}
+
+		if user.Role != *role {
+			previous := user.Role
+			err = a.db.Transaction(func(tx *gorm.DB) error {
+				err := tx.Model(&user).Updates(map[string]interface{}{
+					"role":    *role,
+					"version": gorm.Expr("version + 1"),
+				}).Error
+				if err != nil {
+					return err
+				}
+				return audit.Record(tx, a.actor, audit.Entry{
+					Action:     audit.ActionRoleChange,
+					TargetType: audit.TargetUser,
+					TargetID:   user.ID,
+					Before:     map[string]interface{}{"role": previous},
+					After:      map[string]interface{}{"role": *role},
+				})
+			})
+			if err != nil {
+				return err
+			}
+			user.Role = *role
+		}
+
+		return a.out.print(newUserResult(user), "%s is now %s", user.Username, user.Role)
+	}
+}
+
+func lockUser(flags *flag.FlagSet) func(a *app) error {
+	username := flags.String("username", "", "user to lock")
+
+	return func(a *app) error {
+		user, err := findUser(a.db, *username)
+		if err != nil {
+			return err
+		}
+		if user.IsLocked() {
+			return a.out.print(newUserResult(user), "%s is already locked", user.Username)
+		}
+
+		now := time.Now()
+		var revoked int64
+		err = a.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Update("locked_at", now).Error; err != nil {
+				return err
+			}
+			var err error
+			if revoked, err = revokeSessions(tx, user.ID); err != nil {
+				return err
+			}
+			return audit.Record(tx, a.actor, audit.Entry{
+				Action:     audit.ActionUserLock,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				After:      map[string]interface{}{"revokedSessions": revoked},
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		result := newUserResult(user)
+		result.RevokedSessions = revoked
+		return a.out.print(result, "locked %s, revoked %d sessions", user.Username, revoked)
+	}
+}
+
+func unlockUser(flags *flag.FlagSet) func(a *app) error {
+	username := flags.String("username", "", "user to unlock")
+
+	return func(a *app) error {
+		user, err := findUser(a.db, *username)
+		if err != nil {
+			return err
+		}
+		if !user.IsLocked() {
+			return a.out.print(newUserResult(user), "%s is not locked", user.Username)
+		}
+
+		lockedAt := *user.LockedAt
+		err = a.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Update("locked_at", nil).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, a.actor, audit.Entry{
+				Action:     audit.ActionUserUnlock,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				Before:     map[string]interface{}{"lockedAt": lockedAt},
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		user.LockedAt = nil
+		return a.out.print(newUserResult(user), "unlocked %s", user.Username)
+	}
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"gorm.io/gorm"
+)
+
+// ชนิดของ event ที่ไม่ได้เป็นการแจ้งเตือน
+const (
+	UserRegistered  = "user.registered"
+	PostCreated     = "post.created"
+	PostPublished   = "post.published"
+	PostDeleted     = "post.deleted"
+	FollowCreated   = "follow.created"
+	ReactionChanged = "reaction.changed"
+)
+
+// Event เหตุการณ์ในระบบ เช่น มีคนติดตาม แสดงความคิดเห็น หรือโพสต์ถูก publish
+// ถ้า Type เป็นชนิดการแจ้งเตือนใน models จะถูกบันทึกเป็นการแจ้งเตือนของ UserID
+type Event struct {
+	Type           string    `json:"type"`
+	UserID         uint      `json:"userID,omitempty"`
+	ActorID        uint      `json:"actorID"`
+	PostID         *uint     `json:"postID,omitempty"`
+	CommentID      *uint     `json:"commentID,omitempty"`
+	NotificationID uint      `json:"notificationID,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func isNotification(eventType string) bool {
+	for _, notificationType := range models.NotificationTypes {
+		if notificationType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscriber ถูกเรียกใน transaction เดียวกับที่ส่ง event ถ้าคืน error ทั้ง transaction จะ rollback
+type Subscriber func(tx *gorm.DB, event Event) error
+
+type registration struct {
+	id         int
+	subscriber Subscriber
+}
+
+var (
+	mu          sync.RWMutex
+	nextID      int
+	subscribers []registration
+)
+
+// ลงทะเบียนรับ event ทั้งหมด คืนฟังก์ชันสำหรับยกเลิก ใช้ในการทดสอบ
+func Subscribe(subscriber Subscriber) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	nextID++
+	id := nextID
+	subscribers = append(subscribers, registration{id: id, subscriber: subscriber})
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		// สร้าง slice ใหม่ เพราะ Publish อาจกำลังวนอ่าน slice เดิมอยู่
+		remaining := []registration{}
+		for _, r := range subscribers {
+			if r.id != id {
+				remaining = append(remaining, r)
+			}
+		}
+		subscribers = remaining
+	}
+}
+
+// ส่ง event ให้ subscriber ทุกตัว event ที่เป็นการแจ้งเตือนจะถูกบันทึกก่อน
+// การแจ้งเตือนที่ผู้ใช้ทำกับตัวเอง ระหว่างผู้ใช้ที่บล็อกกัน หรือผู้รับปิดชนิดนี้ไว้จะถูกทิ้งทั้งหมด
+func Publish(tx *gorm.DB, event Event) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if isNotification(event.Type) {
+		id, err := record(tx, event)
+		if err != nil || id == 0 {
+			return err
+		}
+		event.NotificationID = id
+	}
+
+	mu.RLock()
+	current := subscribers
+	mu.RUnlock()
+	for _, r := range current {
+		if err := r.subscriber(tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// บันทึกการแจ้งเตือน คืน id เป็น 0 ถ้าไม่ต้องแจ้งเตือน
+func record(tx *gorm.DB, event Event) (uint, error) {
+	if event.UserID == 0 || event.UserID == event.ActorID {
+		return 0, nil
+	}
+
+	var blocked int64
+	err := tx.Model(&models.Blocks{}).
+		Where("(blockerUserID = ? AND blockedUserID = ?) OR (blockerUserID = ? AND blockedUserID = ?)",
+			event.UserID, event.ActorID, event.ActorID, event.UserID).
+		Count(&blocked).Error
+	if err != nil || blocked > 0 {
+		return 0, err
+	}
+
+	enabled, err := Enabled(tx, event.UserID, event.Type)
+	if err != nil || !enabled {
+		return 0, err
+	}
+
+	notification := models.Notifications{
+		UserID:    event.UserID,
+		ActorID:   event.ActorID,
+		Type:      event.Type,
+		PostID:    event.PostID,
+		CommentID: event.CommentID,
+		CreatedAt: event.CreatedAt,
+	}
+	if err := tx.Create(&notification).Error; err != nil {
+		return 0, err
+	}
+	return notification.ID, nil
+}
+
+// ผู้ใช้เปิดการแจ้งเตือนชนิดนี้อยู่หรือไม่ ค่าเริ่มต้นคือเปิด
+func Enabled(db *gorm.DB, userID uint, notificationType string) (bool, error) {
+	var preferences []models.NotificationPreferences
+	err := db.Where("userID = ? AND type = ?", userID, notificationType).Limit(1).Find(&preferences).Error
+	if err != nil || len(preferences) == 0 {
+		return true, err
+	}
+	return preferences[0].Enabled, nil
+}
+
+// ส่ง event mention ให้ผู้ที่ถูก mention ในโพสต์ที่ published แล้วและยังไม่เคยได้รับแจ้งเตือน
+// ถ้าโพสต์ยังเป็น draft หรือตั้งเวลาไว้จะรอแจ้งเตือนตอน publish เพื่อไม่ให้เนื้อหาที่ยังไม่เผยแพร่หลุดออกไป
+// โพสต์ private ไม่แจ้งเตือนเพราะผู้ถูก mention มองไม่เห็นโพสต์
+func NotifyMentions(tx *gorm.DB, post models.Posts) error {
+	if post.Status != models.PostStatusPublished || post.Visibility == models.VisibilityPrivate {
+		return nil
+	}
+
+	var mentions []models.Mentions
+	result := tx.Where("postID = ? AND notified_at IS NULL AND userID <> ?", post.PostID, post.UserID).Find(&mentions)
+	if result.Error != nil {
+		return result.Error
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	postID := post.PostID
+	userIDs := make([]uint, 0, len(mentions))
+	for _, mention := range mentions {
+		err := Publish(tx, Event{
+			Type:    models.NotificationMention,
+			UserID:  mention.UserID,
+			ActorID: post.UserID,
+			PostID:  &postID,
+		})
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, mention.UserID)
+	}
+
+	return tx.Model(&models.Mentions{}).
+		Where("postID = ? AND userID IN ?", post.PostID, userIDs).
+		Update("notified_at", time.Now()).Error
+}
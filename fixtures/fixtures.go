@@ -0,0 +1,345 @@
+// Package fixtures สร้างข้อมูลตัวอย่างที่ดูสมจริง ได้แก่ ผู้ใช้ การติดตาม โพสต์ ความคิดเห็น และ reaction
+// ค่า seed เดียวกันให้ข้อมูลชุดเดียวกันทุกครั้ง ใช้ได้ทั้งสำหรับ demo ในเครื่องและ load test
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+)
+
+// ชนิด reaction ตามค่าเริ่มต้นของ REACTION_EMOJIS โดย like พบบ่อยที่สุด
+var reactionTypes = []string{"❤️", "😂", "😮", "😢", "😡"}
+
+// Config กำหนดขนาดของข้อมูล ค่าต่อผู้ใช้และต่อโพสต์เป็นค่าเฉลี่ย จำนวนจริงสุ่มอยู่ระหว่าง 0 ถึงสองเท่า
+type Config struct {
+	Seed             int64
+	Users            int
+	FollowsPerUser   int
+	PostsPerUser     int
+	CommentsPerPost  int
+	ReactionsPerPost int
+	// รหัสผ่านของผู้ใช้ทุกคน
+	Password string
+	// นำหน้าชื่อผู้ใช้ ใช้เพิ่มข้อมูลอีกชุดลงฐานข้อมูลที่มีชุดอื่นอยู่แล้วโดยชื่อไม่ซ้ำ
+	Prefix string
+	// ข้อมูลทั้งหมดเกิดขึ้นภายในช่วง Period ก่อน Now ถ้า Now เป็นค่าว่างจะใช้เวลาปัจจุบัน
+	Now    time.Time
+	Period time.Duration
+}
+
+// ขนาดสำหรับ demo ในเครื่อง
+func DefaultConfig() Config {
+	return Config{
+		Seed:             1,
+		Users:            50,
+		FollowsPerUser:   10,
+		PostsPerUser:     5,
+		CommentsPerPost:  3,
+		ReactionsPerPost: 5,
+		Password:         "demo-password",
+		Period:           90 * 24 * time.Hour,
+	}
+}
+
+// Dataset ข้อมูลที่สร้างขึ้น ก่อนบันทึกลงฐานข้อมูล ID และการอ้างอิงเป็นลำดับภายในชุดข้อมูลเริ่มจาก 1
+// ความคิดเห็นที่ไม่มี ParentID อยู่ก่อนความคิดเห็นที่ตอบกลับเสมอ
+type Dataset struct {
+	Users     []models.Users
+	Follows   []models.Follows
+	Posts     []models.Posts
+	Comments  []models.Comments
+	Reactions []models.Reactions
+	Password  string
+}
+
+// Summary จำนวนข้อมูลแต่ละชนิด
+type Summary struct {
+	Users     int `json:"users"`
+	Follows   int `json:"follows"`
+	Posts     int `json:"posts"`
+	Comments  int `json:"comments"`
+	Reactions int `json:"reactions"`
+}
+
+func (d *Dataset) Summary() Summary {
+	return Summary{
+		Users:     len(d.Users),
+		Follows:   len(d.Follows),
+		Posts:     len(d.Posts),
+		Comments:  len(d.Comments),
+		Reactions: len(d.Reactions),
+	}
+}
+
+// generator สถานะระหว่างสร้างข้อมูล ใช้ rng ตัวเดียวตามลำดับที่แน่นอนเพื่อให้ผลลัพธ์ซ้ำได้
+type generator struct {
+	cfg   Config
+	rng   *rand.Rand
+	start time.Time
+	now   time.Time
+	data  *Dataset
+}
+
+// สร้างข้อมูลตาม cfg โดยไม่แตะฐานข้อมูล
+func Generate(cfg Config) (*Dataset, error) {
+	if cfg.Users < 1 {
+		return nil, errors.New("fixtures: Users must be at least 1")
+	}
+	if cfg.FollowsPerUser < 0 || cfg.PostsPerUser < 0 || cfg.CommentsPerPost < 0 || cfg.ReactionsPerPost < 0 {
+		return nil, errors.New("fixtures: counts must not be negative")
+	}
+	if cfg.Period <= 0 {
+		return nil, errors.New("fixtures: Period must be positive")
+	}
+	if cfg.Now.IsZero() {
+		cfg.Now = time.Now()
+	}
+
+	g := &generator{
+		cfg:   cfg,
+		rng:   rand.New(rand.NewSource(cfg.Seed)),
+		start: cfg.Now.Add(-cfg.Period),
+		now:   cfg.Now,
+		data:  &Dataset{Password: cfg.Password},
+	}
+	g.users()
+	g.follows()
+	g.posts()
+	g.comments()
+	g.reactions()
+	return g.data, nil
+}
+
+// จำนวนสุ่มที่มีค่าเฉลี่ยเท่ากับ mean
+func (g *generator) count(mean int) int {
+	return g.rng.Intn(2*mean + 1)
+}
+
+func (g *generator) chance(p float64) bool {
+	return g.rng.Float64() < p
+}
+
+// เวลาสุ่มระหว่าง from ถึง to
+func (g *generator) timeBetween(from, to time.Time) time.Time {
+	span := to.Sub(from)
+	if span <= 0 {
+		return from
+	}
+	return from.Add(time.Duration(g.rng.Int63n(int64(span))))
+}
+
+// เวลาสุ่มระหว่าง from ถึง now
+func (g *generator) timeAfter(from time.Time) time.Time {
+	return g.timeBetween(from, g.now)
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func (g *generator) users() {
+	taken := map[string]bool{}
+	for i := 1; i <= g.cfg.Users; i++ {
+		first := pick(g.rng, firstNames)
+		last := pick(g.rng, lastNames)
+
+		// ชื่อผู้ใช้ต้องไม่ซ้ำและยาวอย่างน้อย 6 ตัวอักษรตามที่ API กำหนด
+		base := strings.ToLower(g.cfg.Prefix + first + "_" + last)
+		username := base
+		for n := 2; taken[username]; n++ {
+			username = fmt.Sprintf("%s%d", base, n)
+		}
+		taken[username] = true
+
+		g.data.Users = append(g.data.Users, models.Users{
+			ID:       uint(i),
+			Username: username,
+			Fullname: first + " " + last,
+			Email:    username + "@" + pick(g.rng, domains),
+			Bio:      pick(g.rng, bios),
+			Location: pick(g.rng, locations),
+			Role:     models.RoleUser,
+			Private:  g.chance(0.1),
+			Version:  1,
+			// ผู้ใช้สมัครในช่วงครึ่งแรก เพื่อให้มีเวลาโพสต์และติดตามกัน
+			CreatedAt: g.timeBetween(g.start, g.start.Add(g.cfg.Period/2)),
+		})
+	}
+}
+
+// ผู้ใช้ที่มีผู้ติดตามมากมีโอกาสถูกติดตามเพิ่มมากกว่า (preferential attachment)
+// จึงได้กราฟที่มีผู้ใช้ยอดนิยมไม่กี่คนเหมือนเครือข่ายจริง
+func (g *generator) follows() {
+	users := g.data.Users
+	// ผู้ใช้แต่ละคนอยู่ใน pool หนึ่งครั้ง และเพิ่มอีกหนึ่งครั้งต่อผู้ติดตามหนึ่งคน
+	pool := make([]int, 0, len(users)*(g.cfg.FollowsPerUser+1))
+	for i := range users {
+		pool = append(pool, i)
+	}
+
+	for _, follower := range g.rng.Perm(len(users)) {
+		want := g.count(g.cfg.FollowsPerUser)
+		if want > len(users)-1 {
+			want = len(users) - 1
+		}
+		chosen := map[int]bool{}
+		for attempts := 0; len(chosen) < want && attempts < 10*want; attempts++ {
+			following := pool[g.rng.Intn(len(pool))]
+			if following == follower || chosen[following] {
+				continue
+			}
+			chosen[following] = true
+			pool = append(pool, following)
+
+			follow := models.Follows{
+				FollowingUserID: users[following].ID,
+				FollowerUserID:  users[follower].ID,
+				Status:          models.FollowStatusAccepted,
+				CreatedAt:       g.timeAfter(later(users[following].CreatedAt, users[follower].CreatedAt)),
+			}
+			// บัญชี private อนุมัติคำขอไปแล้วส่วนใหญ่ ที่เหลือยังรออยู่
+			if users[following].Private && g.chance(0.3) {
+				follow.Status = models.FollowStatusPending
+			} else {
+				acceptedAt := follow.CreatedAt
+				follow.AcceptedAt = &acceptedAt
+			}
+			g.data.Follows = append(g.data.Follows, follow)
+		}
+	}
+}
+
+func (g *generator) posts() {
+	for _, user := range g.data.Users {
+		for n := g.count(g.cfg.PostsPerUser); n > 0; n-- {
+			topic := pick(g.rng, topics)
+			post := models.Posts{
+				PostID:     uint(len(g.data.Posts) + 1),
+				Title:      postTitle(g.rng, topic),
+				Body:       postBody(g.rng, topic),
+				UserID:     user.ID,
+				Status:     models.PostStatusPublished,
+				Visibility: models.VisibilityPublic,
+				Version:    1,
+				CreatedAt:  g.timeAfter(user.CreatedAt),
+			}
+			switch r := g.rng.Float64(); {
+			case r < 0.05:
+				post.Status = models.PostStatusDraft
+			case r < 0.25:
+				post.Visibility = models.VisibilityFollowers
+			case r < 0.3:
+				post.Visibility = models.VisibilityPrivate
+			}
+			if post.Status == models.PostStatusPublished {
+				publishAt := post.CreatedAt
+				post.PublishAt = &publishAt
+			}
+			g.data.Posts = append(g.data.Posts, post)
+		}
+	}
+}
+
+// ความคิดเห็นและ reaction เกิดเฉพาะกับโพสต์ public ที่เผยแพร่แล้ว ซึ่งทุกคนมองเห็น
+func (g *generator) visible(post models.Posts) bool {
+	if post.Status != models.PostStatusPublished || post.Visibility != models.VisibilityPublic {
+		return false
+	}
+	return !g.data.Users[post.UserID-1].Private
+}
+
+func (g *generator) randomUser() models.Users {
+	return g.data.Users[g.rng.Intn(len(g.data.Users))]
+}
+
+func (g *generator) comments() {
+	type thread struct {
+		post     models.Posts
+		comments []models.Comments
+	}
+	var threads []thread
+	for _, post := range g.data.Posts {
+		if !g.visible(post) {
+			continue
+		}
+		t := thread{post: post}
+		for n := g.count(g.cfg.CommentsPerPost); n > 0; n-- {
+			author := g.randomUser()
+			createdAt := g.timeAfter(later(post.CreatedAt, author.CreatedAt))
+			comment := models.Comments{
+				ID:        uint(len(g.data.Comments) + 1),
+				PostID:    post.PostID,
+				UserID:    author.ID,
+				Body:      pick(g.rng, comments),
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			}
+			g.data.Comments = append(g.data.Comments, comment)
+			t.comments = append(t.comments, comment)
+		}
+		threads = append(threads, t)
+	}
+
+	// ตอบกลับความคิดเห็นระดับบนสุด ส่วนใหญ่ตอบโดยเจ้าของโพสต์
+	for _, t := range threads {
+		for _, parent := range t.comments {
+			if !g.chance(0.25) {
+				continue
+			}
+			author := g.data.Users[t.post.UserID-1]
+			if g.chance(0.3) {
+				author = g.randomUser()
+			}
+			parentID := parent.ID
+			createdAt := g.timeAfter(later(parent.CreatedAt, author.CreatedAt))
+			g.data.Comments = append(g.data.Comments, models.Comments{
+				ID:        uint(len(g.data.Comments) + 1),
+				PostID:    t.post.PostID,
+				UserID:    author.ID,
+				ParentID:  &parentID,
+				Body:      pick(g.rng, replies),
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			})
+		}
+	}
+}
+
+func (g *generator) reactions() {
+	for _, post := range g.data.Posts {
+		if !g.visible(post) {
+			continue
+		}
+		want := g.count(g.cfg.ReactionsPerPost)
+		if want > len(g.data.Users) {
+			want = len(g.data.Users)
+		}
+		// ผู้ใช้หนึ่งคนกด reaction ได้ครั้งเดียวต่อโพสต์ในข้อมูลชุดนี้
+		reacted := map[uint]bool{}
+		for len(reacted) < want {
+			user := g.randomUser()
+			if reacted[user.ID] {
+				continue
+			}
+			reacted[user.ID] = true
+			reactionType := "like"
+			if g.chance(0.3) {
+				reactionType = pick(g.rng, reactionTypes)
+			}
+			g.data.Reactions = append(g.data.Reactions, models.Reactions{
+				PostID:    post.PostID,
+				UserID:    user.ID,
+				Type:      reactionType,
+				CreatedAt: g.timeAfter(later(post.CreatedAt, user.CreatedAt)),
+			})
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package fixtures
+
+import (
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig(seed int64) Config {
+	cfg := DefaultConfig()
+	cfg.Seed = seed
+	cfg.Users = 200
+	cfg.Now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return cfg
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	first, err := Generate(testConfig(42))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	second, err := Generate(testConfig(42))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	assert.Equal(t, first, second)
+
+	// seed ต่างกันได้ข้อมูลต่างกัน
+	other, err := Generate(testConfig(43))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	assert.NotEqual(t, first.Users, other.Users)
+}
+
+func TestGenerateIntegrity(t *testing.T) {
+	cfg := testConfig(7)
+	data, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	summary := data.Summary()
+	assert.Equal(t, cfg.Users, summary.Users)
+	assert.NotZero(t, summary.Follows)
+	assert.NotZero(t, summary.Posts)
+	assert.NotZero(t, summary.Comments)
+	assert.NotZero(t, summary.Reactions)
+
+	usernames := map[string]bool{}
+	for i, user := range data.Users {
+		assert.Equal(t, uint(i+1), user.ID)
+		assert.False(t, usernames[user.Username], user.Username)
+		usernames[user.Username] = true
+		assert.GreaterOrEqual(t, utf8.RuneCountInString(user.Username), 6)
+		assert.GreaterOrEqual(t, utf8.RuneCountInString(user.Fullname), 6)
+		assert.False(t, user.CreatedAt.After(cfg.Now))
+	}
+
+	validUser := func(id uint) bool { return id >= 1 && int(id) <= len(data.Users) }
+	validPost := func(id uint) bool { return id >= 1 && int(id) <= len(data.Posts) }
+
+	type pair struct{ a, b uint }
+	follows := map[pair]bool{}
+	for _, follow := range data.Follows {
+		assert.True(t, validUser(follow.FollowerUserID))
+		assert.True(t, validUser(follow.FollowingUserID))
+		assert.NotEqual(t, follow.FollowerUserID, follow.FollowingUserID)
+		key := pair{follow.FollowerUserID, follow.FollowingUserID}
+		assert.False(t, follows[key], "duplicate follow")
+		follows[key] = true
+		assert.Equal(t, follow.Status == models.FollowStatusAccepted, follow.AcceptedAt != nil)
+	}
+
+	for i, post := range data.Posts {
+		assert.Equal(t, uint(i+1), post.PostID)
+		assert.True(t, validUser(post.UserID))
+		assert.NotEmpty(t, post.Title)
+		assert.NotEmpty(t, post.Body)
+		assert.Equal(t, post.Status == models.PostStatusPublished, post.PublishAt != nil)
+	}
+
+	seenReply := false
+	for i, comment := range data.Comments {
+		assert.Equal(t, uint(i+1), comment.ID)
+		assert.True(t, validUser(comment.UserID))
+		if !assert.True(t, validPost(comment.PostID)) {
+			continue
+		}
+		post := data.Posts[comment.PostID-1]
+		assert.Equal(t, models.VisibilityPublic, post.Visibility)
+		assert.False(t, comment.CreatedAt.Before(post.CreatedAt))
+
+		if comment.ParentID == nil {
+			assert.False(t, seenReply, "top-level comment after a reply")
+			continue
+		}
+		seenReply = true
+		parent := data.Comments[*comment.ParentID-1]
+		assert.Nil(t, parent.ParentID)
+		assert.Equal(t, parent.PostID, comment.PostID)
+	}
+
+	reactions := map[pair]bool{}
+	for _, reaction := range data.Reactions {
+		assert.True(t, validUser(reaction.UserID))
+		assert.True(t, validPost(reaction.PostID))
+		key := pair{reaction.PostID, reaction.UserID}
+		assert.False(t, reactions[key], "duplicate reaction")
+		reactions[key] = true
+	}
+}
+
+func TestGenerateInvalidConfig(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.Users = 0
+	_, err := Generate(cfg)
+	assert.Error(t, err)
+
+	cfg = testConfig(1)
+	cfg.PostsPerUser = -1
+	_, err = Generate(cfg)
+	assert.Error(t, err)
+
+	// ผู้ใช้คนเดียวติดตามใครไม่ได้
+	cfg = testConfig(1)
+	cfg.Users = 1
+	data, err := Generate(cfg)
+	assert.NoError(t, err)
+	assert.Empty(t, data.Follows)
+}
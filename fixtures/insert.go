@@ -0,0 +1,135 @@
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"gorm.io/gorm"
+)
+
+// จำนวนชื่อผู้ใช้ที่ตรวจซ้ำต่อหนึ่ง query
+const conflictChunk = 1000
+
+// ErrLoaded ผู้ใช้ทุกคนในข้อมูลชุดนี้มีอยู่ในฐานข้อมูลแล้ว คือเคยบันทึกด้วย Config เดียวกันไปแล้ว
+var ErrLoaded = errors.New("fixtures: data set is already loaded")
+
+// บันทึก data ลงฐานข้อมูลใน transaction เดียว ครั้งละ batchSize แถว
+// เขียนตรงลงตารางโดยไม่ส่ง event ไม่บันทึก audit log และไม่สร้างการแจ้งเตือน
+// เมื่อสำเร็จ ID และการอ้างอิงใน data จะเป็นค่าจริงในฐานข้อมูล ถ้าคืน error ห้ามใช้ data ต่อ
+// ถ้าข้อมูลชุดนี้ถูกบันทึกไว้แล้วคืน ErrLoaded โดยไม่บันทึกซ้ำ จึงรันซ้ำได้
+func Insert(db *gorm.DB, data *Dataset, batchSize int) error {
+	conflicts, err := countConflicts(db, data.Users)
+	if err != nil {
+		return err
+	}
+	if conflicts >= int64(len(data.Users)) {
+		return ErrLoaded
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("fixtures: %d generated users already exist, use a different prefix", conflicts)
+	}
+
+	// bcrypt ช้า จึงใช้ hash เดียวกันทุกคน
+	hashed, err := utils.HashPassword(data.Password)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		users := data.Users
+		for i := range users {
+			users[i].ID = 0
+			users[i].HashedPassword = hashed
+		}
+		if err := createInBatches(tx, users, batchSize); err != nil {
+			return err
+		}
+		// ลำดับภายในชุดข้อมูลเริ่มจาก 1 จึงหา ID จริงจากตำแหน่งใน slice
+		userID := func(local uint) uint { return users[local-1].ID }
+
+		for i := range data.Follows {
+			follow := &data.Follows[i]
+			follow.FollowingUserID = userID(follow.FollowingUserID)
+			follow.FollowerUserID = userID(follow.FollowerUserID)
+		}
+		if err := createInBatches(tx, data.Follows, batchSize); err != nil {
+			return err
+		}
+
+		posts := data.Posts
+		for i := range posts {
+			posts[i].PostID = 0
+			posts[i].UserID = userID(posts[i].UserID)
+		}
+		if err := createInBatches(tx, posts, batchSize); err != nil {
+			return err
+		}
+		postID := func(local uint) uint { return posts[local-1].PostID }
+
+		// ความคิดเห็นระดับบนสุดต้องได้ ID จริงก่อน จึงจะบันทึกความคิดเห็นที่ตอบกลับได้
+		comments := data.Comments
+		split := len(comments)
+		for i := range comments {
+			if comments[i].ParentID != nil && split == len(comments) {
+				split = i
+			}
+			comments[i].ID = 0
+			comments[i].PostID = postID(comments[i].PostID)
+			comments[i].UserID = userID(comments[i].UserID)
+		}
+		if err := createInBatches(tx, comments[:split], batchSize); err != nil {
+			return err
+		}
+		for i := split; i < len(comments); i++ {
+			parentID := comments[*comments[i].ParentID-1].ID
+			comments[i].ParentID = &parentID
+		}
+		if err := createInBatches(tx, comments[split:], batchSize); err != nil {
+			return err
+		}
+
+		for i := range data.Reactions {
+			reaction := &data.Reactions[i]
+			reaction.PostID = postID(reaction.PostID)
+			reaction.UserID = userID(reaction.UserID)
+		}
+		return createInBatches(tx, data.Reactions, batchSize)
+	})
+}
+
+// gorm ไม่ยอมรับ slice ว่าง
+func createInBatches[T any](tx *gorm.DB, rows []T, batchSize int) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(rows, batchSize).Error
+}
+
+// จำนวนผู้ใช้ที่ชื่อหรืออีเมลซ้ำกับที่มีอยู่ รวมผู้ใช้ในถังขยะด้วยเหมือนการสมัคร
+func countConflicts(db *gorm.DB, users []models.Users) (int64, error) {
+	var conflicts int64
+	for start := 0; start < len(users); start += conflictChunk {
+		end := start + conflictChunk
+		if end > len(users) {
+			end = len(users)
+		}
+		usernames := make([]string, 0, end-start)
+		emails := make([]string, 0, end-start)
+		for _, user := range users[start:end] {
+			usernames = append(usernames, user.Username)
+			emails = append(emails, user.Email)
+		}
+
+		var count int64
+		err := db.Unscoped().Model(&models.Users{}).
+			Where("username IN ? OR email IN ?", usernames, emails).
+			Count(&count).Error
+		if err != nil {
+			return 0, err
+		}
+		conflicts += count
+	}
+	return conflicts, nil
+}
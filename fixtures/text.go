@@ -0,0 +1,123 @@
+package fixtures
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+var firstNames = []string{
+	"Somchai", "Suda", "Anan", "Malee", "Niran", "Kanya", "Prasert", "Wanida",
+	"Thanawat", "Pimchanok", "Kittipong", "Siriporn", "Apinya", "Chaiwat", "Nattaya", "Worawit",
+	"Alice", "Benjamin", "Chloe", "Daniel", "Emily", "Felix", "Grace", "Henry",
+	"Isabel", "James", "Kate", "Liam", "Maya", "Noah", "Olivia", "Peter",
+}
+
+var lastNames = []string{
+	"Srisuk", "Chaiyaporn", "Wongsakul", "Rattanakorn", "Saetang", "Boonmee", "Phromma", "Kongkaew",
+	"Jitpakdee", "Thongdee", "Suwannarat", "Inthavong", "Anderson", "Brown", "Clarke", "Davis",
+	"Evans", "Fischer", "Garcia", "Harris", "Ito", "Jensen", "Kim", "Lopez",
+}
+
+var domains = []string{"example.com", "example.org", "example.net"}
+
+var topics = []string{
+	"street food", "Go generics", "weekend hiking", "coffee brewing", "remote work",
+	"database indexes", "film photography", "Bangkok traffic", "home gardening", "board games",
+	"running a marathon", "learning Thai", "mechanical keyboards", "night markets", "code review",
+	"travel on a budget", "indie music", "minimalist design", "baking bread", "open source",
+}
+
+var titleTemplates = []string{
+	"Thoughts on %s",
+	"What I learned about %s",
+	"A beginner's guide to %s",
+	"Why I changed my mind on %s",
+	"%s: a short story",
+	"Five tips for %s",
+	"Is %s worth it?",
+	"My week with %s",
+}
+
+var openers = []string{
+	"I have been thinking about %s a lot lately.",
+	"Last weekend I finally tried %s.",
+	"Everyone keeps asking me about %s.",
+	"Here is a quick update on %s.",
+	"I never expected %s to be this much fun.",
+	"A friend convinced me to look into %s.",
+}
+
+var middles = []string{
+	"The first few days were harder than I thought.",
+	"It took some practice, but it started to make sense.",
+	"Most of the advice online turned out to be wrong.",
+	"The community around it is surprisingly friendly.",
+	"I made plenty of mistakes along the way.",
+	"Small habits made the biggest difference.",
+	"It is cheaper than I expected.",
+	"The hardest part was simply getting started.",
+}
+
+var closers = []string{
+	"Would love to hear what you think.",
+	"More on this next week.",
+	"Let me know if you have any tips!",
+	"I will post photos soon.",
+	"Definitely doing this again.",
+	"Not sure yet if I would recommend it.",
+}
+
+var comments = []string{
+	"Great post, thanks for sharing!",
+	"I had the same experience.",
+	"This is really helpful.",
+	"Totally agree with this.",
+	"Interesting, I never thought about it that way.",
+	"Can you share more details?",
+	"Saved for later!",
+	"Haha, this made my day.",
+	"I disagree, but nicely written.",
+	"Where did you find this?",
+	"Looking forward to the next one.",
+	"Same here!",
+}
+
+var replies = []string{
+	"Thanks!",
+	"Glad it helped.",
+	"Good point.",
+	"I will write a follow-up soon.",
+	"Exactly what I was thinking.",
+	"Let me check and get back to you.",
+}
+
+var bios = []string{
+	"Coffee first, code later.",
+	"Sharing what I learn along the way.",
+	"Weekend hiker and weekday developer.",
+	"Food, travel and the occasional rant.",
+	"Trying to read more books this year.",
+	"",
+}
+
+var locations = []string{"Bangkok", "Chiang Mai", "Phuket", "Khon Kaen", "Singapore", "Tokyo", "Berlin", "London", ""}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.Intn(len(values))]
+}
+
+func postTitle(rng *rand.Rand, topic string) string {
+	title := fmt.Sprintf(pick(rng, titleTemplates), topic)
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// เนื้อหาโพสต์ 2 ถึง 4 ประโยค
+func postBody(rng *rand.Rand, topic string) string {
+	sentences := []string{fmt.Sprintf(pick(rng, openers), topic)}
+	for i := rng.Intn(3); i > 0; i-- {
+		sentences = append(sentences, pick(rng, middles))
+	}
+	sentences = append(sentences, pick(rng, closers))
+	return strings.Join(sentences, " ")
+}
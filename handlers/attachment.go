@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ขนาดด้านยาวสุดของ thumbnail
+const thumbnailSize = 320
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type AttachmentHandler struct {
+	db             *gorm.DB
+	store          storage.Storage
+	maxUploadSize  int64
+	maxAttachments int64
+}
+
+func NewAttachmentHandler(db *gorm.DB, store storage.Storage) *AttachmentHandler {
+	return &AttachmentHandler{
+		db:             db,
+		store:          store,
+		maxUploadSize:  int64(utils.IntEnv("MAX_UPLOAD_SIZE", 10<<20)),
+		maxAttachments: int64(utils.IntEnv("MAX_ATTACHMENTS_PER_POST", 10)),
+	}
+}
+
+type AttachmentResponse struct {
+	ID           uint      `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailURL"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func newAttachmentResponse(attachment models.Attachments) AttachmentResponse {
+	return AttachmentResponse{
+		ID:           attachment.ID,
+		URL:          attachment.URL,
+		ThumbnailURL: attachment.ThumbnailURL,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		Width:        attachment.Width,
+		Height:       attachment.Height,
+		CreatedAt:    attachment.CreatedAt,
+	}
+}
+
+// อ่านไฟล์รูปจาก multipart field ชื่อ file ตรวจขนาดและชนิดไฟล์จากเนื้อหาจริง
+// ถ้าไม่ผ่านจะตอบ error ให้ client แล้วคืน false
+func readImageUpload(c *gin.Context, maxSize int64) ([]byte, string, bool) {
+	// เผื่อพื้นที่ให้ส่วนหัวของ multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return nil, "", false
+	}
+	if header.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+		return nil, "", false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+	if int64(len(data)) > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+		return nil, "", false
+	}
+
+	// ไม่เชื่อ Content-Type ที่ client ส่งมา ตรวจจากเนื้อไฟล์แทน
+	contentType := http.DetectContentType(data)
+	if !utils.ImageContentTypes[contentType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported file type " + contentType})
+		return nil, "", false
+	}
+
+	return data, contentType, true
+}
+
+// POST /posts/:id/attachments แนบรูปกับโพสต์ได้เฉพาะเจ้าของโพสต์
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return
+	}
+	if post.UserID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can add attachments"})
+		return
+	}
+
+	var count int64
+	if err := h.db.Model(&models.Attachments{}).Where("postID = ?", post.PostID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if count >= h.maxAttachments {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("a post can have at most %d attachments", h.maxAttachments)})
+		return
+	}
+
+	data, contentType, ok := readImageUpload(c, h.maxUploadSize)
+	if !ok {
+		return
+	}
+
+	// encode ใหม่เพื่อตัด EXIF และสร้าง thumbnail
+	clean, img, err := utils.SanitizeImage(data, contentType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image: " + err.Error()})
+		return
+	}
+	thumbType := utils.ThumbnailContentType(contentType)
+	thumb, err := utils.EncodeImage(utils.Thumbnail(img, thumbnailSize), thumbType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := utils.GenerateRandomString(16)
+	key := fmt.Sprintf("posts/%d/%s%s", post.PostID, name, imageExtensions[contentType])
+	thumbKey := fmt.Sprintf("posts/%d/%s_thumb%s", post.PostID, name, imageExtensions[thumbType])
+
+	ctx := c.Request.Context()
+	if err := h.store.Put(ctx, key, bytes.NewReader(clean), int64(len(clean)), contentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.store.Put(ctx, thumbKey, bytes.NewReader(thumb), int64(len(thumb)), thumbType); err != nil {
+		h.removeBlobs(key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	bounds := img.Bounds()
+	attachment := models.Attachments{
+		PostID:       post.PostID,
+		UserID:       user.ID,
+		Key:          key,
+		ThumbnailKey: thumbKey,
+		URL:          h.store.URL(key),
+		ThumbnailURL: h.store.URL(thumbKey),
+		ContentType:  contentType,
+		Size:         int64(len(clean)),
+		Width:        bounds.Dx(),
+		Height:       bounds.Dy(),
+	}
+	if err := h.db.Create(&attachment).Error; err != nil {
+		h.removeBlobs(key, thumbKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newAttachmentResponse(attachment))
+}
+
+// DELETE /posts/:id/attachments/:attachmentID
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return
+	}
+	if post.UserID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can remove attachments"})
+		return
+	}
+
+	var attachment models.Attachments
+	result := h.db.Where("postID = ?", post.PostID).First(&attachment, c.Param("attachmentID"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if err := h.db.Delete(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.removeBlobs(attachment.Key, attachment.ThumbnailKey)
+
+	c.JSON(http.StatusNoContent, gin.H{"Success": "removed record"})
+}
+
+// ลบไฟล์ใน storage ถ้าลบไม่สำเร็จแค่ log ไว้ เพราะแถวใน database ถูกจัดการไปแล้ว
+func (h *AttachmentHandler) removeBlobs(keys ...string) {
+	for _, key := range keys {
+		if err := h.store.Delete(context.Background(), key); err != nil {
+			log.Printf("attachments: delete %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// สร้างไฟล์ JPEG ที่มี EXIF segment แทรกอยู่หลัง SOI
+func jpegWithExif(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, nil)
+	assert.NoError(t, err)
+
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x00GPS-SECRET")
+	segment := append([]byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}, exif...)
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestUploadAttachment(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์
+	db, user, post := setupTestData(t)
+
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir, "/media")
+	assert.NoError(t, err)
+	attachmentHandler := handlers.NewAttachmentHandler(db, store)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "photo.jpg")
+	assert.NoError(t, err)
+	part.Write(jpegWithExif(t, 800, 600))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+	c.Request, _ = http.NewRequest("POST", "/posts/1/attachments", &body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	attachmentHandler.UploadAttachment(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response handlers.AttachmentResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", response.ContentType)
+	assert.Equal(t, 800, response.Width)
+	assert.Equal(t, 600, response.Height)
+
+	// ไฟล์ที่เก็บต้องไม่มี EXIF และ thumbnail ถูกย่อแล้ว
+	stored, err := os.ReadFile(filepath.Join(dir, response.URL[len("/media/"):]))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(stored), "GPS-SECRET")
+
+	thumbFile, err := os.Open(filepath.Join(dir, response.ThumbnailURL[len("/media/"):]))
+	assert.NoError(t, err)
+	defer thumbFile.Close()
+	thumb, err := jpeg.DecodeConfig(thumbFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 320, thumb.Width)
+	assert.Equal(t, 240, thumb.Height)
+}
+
+func TestUploadAttachmentRejectsNonImage(t *testing.T) {
+	db, user, post := setupTestData(t)
+
+	store, err := storage.NewLocal(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	attachmentHandler := handlers.NewAttachmentHandler(db, store)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "photo.jpg")
+	assert.NoError(t, err)
+	part.Write([]byte("#!/bin/sh\necho not an image\n"))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+	c.Request, _ = http.NewRequest("POST", "/posts/1/attachments", &body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	attachmentHandler.UploadAttachment(c)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	db, admin, _ := setupTestData(t)
+	assert.NoError(t, db.Model(&admin).Update("role", models.RoleAdmin).Error)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+	auditHandler := handlers.NewAuditHandler(db)
+
+	request := func(username, requestID, method, path, body string, params gin.Params, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		if username != "" {
+			c.Set("username", username)
+		}
+		c.Set("requestID", requestID)
+		c.Params = params
+		c.Request, _ = http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		handle(c)
+		return w
+	}
+	type list struct {
+		Items []handlers.AuditLogResponse `json:"items"`
+		Total int64                       `json:"total"`
+	}
+	search := func(query string) list {
+		var result list
+		w := request(admin.Username, "", "GET", "/audit-logs?"+query, "", nil, auditHandler.ListAuditLogs)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	register, _ := json.Marshal(handlers.CreateUserRequest{
+		Username:       "bobby_b",
+		HashedPassword: "correct-horse-battery",
+		FullName:       "Bobby Brown",
+		Email:          "bobby@example.com",
+	})
+	w := request("", "req-register", "POST", "/register", string(register), nil, userHandler.Register)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var bobby handlers.CreateUserResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &bobby))
+	bobbyID := strconv.FormatUint(uint64(bobby.ID), 10)
+
+	w = request("", "req-login", "POST", "/login", `{"username": "bobby_b", "password": "wrong-password"}`, nil, userHandler.Login)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// ผู้ใช้ทั่วไปเปลี่ยน role และดู audit log ไม่ได้
+	w = request("bobby_b", "", "PUT", "/users/"+bobbyID+"/role", `{"role": "admin"}`, gin.Params{{Key: "id", Value: bobbyID}}, userHandler.ChangeRole)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = request("bobby_b", "", "GET", "/audit-logs", "", nil, auditHandler.ListAuditLogs)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	adminID := strconv.FormatUint(uint64(admin.ID), 10)
+	w = request(admin.Username, "", "PUT", "/users/"+adminID+"/role", `{"role": "user"}`, gin.Params{{Key: "id", Value: adminID}}, userHandler.ChangeRole)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = request(admin.Username, "req-role", "PUT", "/users/"+bobbyID+"/role", `{"role": "admin"}`, gin.Params{{Key: "id", Value: bobbyID}}, userHandler.ChangeRole)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	registered := search("requestID=req-register")
+	assert.Equal(t, int64(1), registered.Total)
+	assert.Equal(t, audit.ActionRegister, registered.Items[0].Action)
+	assert.Equal(t, bobby.ID, registered.Items[0].ActorID)
+	assert.JSONEq(t, `{"username": "bobby_b", "email": "bobby@example.com"}`, string(registered.Items[0].After))
+
+	failed := search("action=" + audit.ActionLoginFailed)
+	assert.Equal(t, int64(1), failed.Total)
+	assert.Equal(t, "bobby_b", failed.Items[0].ActorName)
+	assert.Equal(t, "req-login", failed.Items[0].RequestID)
+
+	roles := search("targetType=user&targetID=" + bobbyID + "&action=" + audit.ActionRoleChange)
+	assert.Equal(t, int64(1), roles.Total)
+	assert.Equal(t, admin.ID, roles.Items[0].ActorID)
+	assert.JSONEq(t, `{"role": "user"}`, string(roles.Items[0].Before))
+	assert.JSONEq(t, `{"role": "admin"}`, string(roles.Items[0].After))
+
+	w = request(admin.Username, "", "GET", "/audit-logs?from=yesterday", "", nil, auditHandler.ListAuditLogs)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// ส่งออกเป็น CSV ได้ทุกรายการพร้อม header และการส่งออกถูกบันทึกด้วย
+	w = request(admin.Username, "", "GET", "/audit-logs/export?format=csv", "", nil, auditHandler.ExportAuditLogs)
+	assert.Equal(t, http.StatusOK, w.Code)
+	rows, err := csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "action", rows[0][4])
+	assert.Equal(t, 4, len(rows)-1)
+	assert.Equal(t, audit.ActionAuditExport, rows[len(rows)-1][4])
+
+	w = request(admin.Username, "", "GET", "/audit-logs/export?format=json&action="+audit.ActionRoleChange, "", nil, auditHandler.ExportAuditLogs)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var exported []handlers.AuditLogResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+	assert.Len(t, exported, 1)
+}
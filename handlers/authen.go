@@ -1,16 +1,23 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/events"
 	"github.com/NopparootSuree/go-social/models"
 	"github.com/NopparootSuree/go-social/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
 )
 
+// อายุของ token ที่ได้จากการ login
+const tokenTTL = time.Hour * 3
+
 // จัดการ payload
 type Payload struct {
 	Token     string    `json:"token"`
@@ -40,6 +47,11 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if err := h.policy.Validate(req.HashedPassword, req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	hashPassword, err := utils.HashPassword(req.HashedPassword)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -47,7 +59,8 @@ func (h *UserHandler) Register(c *gin.Context) {
 	}
 
 	var existingUser models.Users
-	h.db.Where("username = ?", req.Username).Or("email = ?", req.Email).First(&existingUser)
+	// รวมผู้ใช้ในถังขยะด้วย เพื่อให้ยังกู้คืนได้โดยชื่อไม่ซ้ำ
+	h.db.Unscoped().Where("username = ?", req.Username).Or("email = ?", req.Email).First(&existingUser)
 	if existingUser.ID != 0 {
 		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
 		return
@@ -58,21 +71,31 @@ func (h *UserHandler) Register(c *gin.Context) {
 		HashedPassword: hashPassword,
 		Fullname:       req.FullName,
 		Email:          req.Email,
+		Role:           models.RoleUser,
+		Version:        1,
 	}
 
-	created := h.db.Create(&user)
-	if created.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": created.Error.Error()})
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		err := audit.Record(tx, auditActor(c, user), audit.Entry{
+			Action:     audit.ActionRegister,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			After:      map[string]interface{}{"username": user.Username, "email": user.Email},
+		})
+		if err != nil {
+			return err
+		}
+		return events.Publish(tx, events.Event{Type: events.UserRegistered, ActorID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	response := CreateUserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		FullName:  user.Fullname,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-	}
+	response := newUserResponse(user, user)
 
 	c.JSON(http.StatusCreated, response)
 
@@ -89,6 +112,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 	var user models.Users
 	result := h.db.Where("username = ?", loginReq.Username).First(&user)
 	if result.Error != nil {
+		h.auditLoginFailed(c, models.Users{Username: loginReq.Username})
 		c.JSON(http.StatusBadRequest, gin.H{"error": result.Error.Error()})
 		return
 	}
@@ -100,17 +124,33 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 	err := utils.ComparePasswords(user.HashedPassword, loginReq.Password)
 	if !err {
+		h.auditLoginFailed(c, user)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Login failed"})
 		return
 	}
 
+	// แจ้งว่าถูกล็อกหลังตรวจรหัสผ่านแล้ว เพื่อไม่ให้ใช้เดาว่าบัญชีไหนถูกล็อก
+	if user.IsLocked() {
+		h.auditLoginFailed(c, user)
+		c.JSON(http.StatusForbidden, gin.H{"error": "account is locked"})
+		return
+	}
+
+	// สร้าง session ไว้ยกเลิก token นี้ภายหลังได้
+	session, errSession := createSession(h.db, user.ID, tokenTTL)
+	if errSession != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errSession.Error()})
+		return
+	}
+
 	//สร้าง secretKey
 	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
 
 	//ปรับแต่ง key
 	claims := jwt.MapClaims{
 		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * 3).Unix(),
+		"sid":      session.ID,
+		"exp":      session.ExpiresAt.Unix(),
 	}
 
 	// สร้าง Token
@@ -123,12 +163,34 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// login สำเร็จแล้ว ถ้าบันทึก audit ไม่ได้แค่ log ไว้ ไม่ทำให้ login ล้มเหลว
+	errAudit := audit.Record(h.db, auditActor(c, user), audit.Entry{
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
+	if errAudit != nil {
+		log.Printf("audit: record login: %v", errAudit)
+	}
+
 	payload := &Payload{
 		Token:     token,
 		Username:  user.Username,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(time.Hour * 3),
+		IssuedAt:  session.CreatedAt,
+		ExpiredAt: session.ExpiresAt,
 	}
 
 	c.JSON(http.StatusOK, gin.H{"payload": payload})
 }
+
+// บันทึก login ที่ไม่สำเร็จ user.ID เป็น 0 ถ้าไม่มีชื่อผู้ใช้นี้
+func (h *UserHandler) auditLoginFailed(c *gin.Context, user models.Users) {
+	err := audit.Record(h.db, auditActor(c, user), audit.Entry{
+		Action:     audit.ActionLoginFailed,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
+	if err != nil {
+		log.Printf("audit: record failed login: %v", err)
+	}
+}
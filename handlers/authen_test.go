@@ -25,11 +25,12 @@ func TestRegisterUser(t *testing.T) {
 	assert.NoError(t, err)
 	teardownTestDB(db)
 	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Users{})
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{})
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
-	userHandler := handlers.NewUserHandler(db)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
 
 	// สร้างเครื่องมือทดสอบ HTTP และเรียกใช้งานฟังก์ชัน CreateUser
 	w := httptest.NewRecorder()
@@ -85,11 +86,12 @@ func TestLogin(t *testing.T) {
 		t.Fatalf("failed to connect to database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.Users{})
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{}, &models.Sessions{})
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler พร้อมกำหนดค่าฐานข้อมูล
-	userHandler := handlers.NewUserHandler(db)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
 
 	// เรียกใช้งานเส้นทางและรับการตอบสนอง
 	w := httptest.NewRecorder()
@@ -122,3 +124,55 @@ func TestLogin(t *testing.T) {
 	assert.WithinDuration(t, time.Now(), payload.Payload.IssuedAt, time.Second)
 	assert.WithinDuration(t, time.Now().Add(time.Hour*3), payload.Payload.ExpiredAt, time.Second)
 }
+
+func TestLoginLockedAccount(t *testing.T) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{}, &models.Sessions{})
+	assert.NoError(t, err)
+
+	// ผู้ใช้ที่ถูกล็อก
+	hashed, err := utils.HashPassword("password123")
+	assert.NoError(t, err)
+	lockedAt := time.Now()
+	user := models.Users{
+		Username:       "locked_user",
+		HashedPassword: hashed,
+		Fullname:       "Locked User",
+		Email:          "locked@example.com",
+		LockedAt:       &lockedAt,
+	}
+	assert.NoError(t, db.Create(&user).Error)
+	defer db.Unscoped().Delete(&user)
+
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+	login := func(password string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body, _ := json.Marshal(handlers.LoginUserRequest{Username: user.Username, Password: password})
+		c.Request, _ = http.NewRequest("POST", "login", bytes.NewReader(body))
+		userHandler.Login(c)
+		return w
+	}
+
+	// รหัสผ่านผิดได้คำตอบเหมือนบัญชีปกติ
+	assert.Equal(t, http.StatusBadRequest, login("wrong-password").Code)
+
+	// รหัสผ่านถูกแต่บัญชีถูกล็อก
+	w := login("password123")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "account is locked")
+
+	var sessions int64
+	db.Model(&models.Sessions{}).Where("userID = ?", user.ID).Count(&sessions)
+	assert.Equal(t, int64(0), sessions)
+
+	// ปลดล็อกแล้ว login ได้
+	assert.NoError(t, db.Model(&user).Update("locked_at", nil).Error)
+	assert.Equal(t, http.StatusOK, login("password123").Code)
+}
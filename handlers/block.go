@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlockHandler struct {
+	db *gorm.DB
+}
+
+func NewBlockHandler(db *gorm.DB) *BlockHandler {
+	return &BlockHandler{
+		db: db,
+	}
+}
+
+// ผู้ใช้ที่ถูกบล็อกหรือ mute พร้อมเวลาที่ทำ
+type RelatedUserResponse struct {
+	User      CreateUserResponse `json:"user"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+// ตรวจว่าผู้ใช้สองคนบล็อกกันไม่ว่าทางไหน
+func isBlocked(db *gorm.DB, a, b uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Blocks{}).
+		Where("(blockerUserID = ? AND blockedUserID = ?) OR (blockerUserID = ? AND blockedUserID = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// หาผู้ใช้ที่ login อยู่และผู้ใช้เป้าหมายจาก :id ถ้าไม่เจอหรือเป็นคนเดียวกันจะตอบ error และคืน false
+func (h *BlockHandler) targetUser(c *gin.Context) (models.Users, models.Users, bool) {
+	var target models.Users
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return user, target, false
+	}
+
+	result := h.db.First(&target, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return user, target, false
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return user, target, false
+	}
+	if target.ID == user.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot do this to yourself"})
+		return user, target, false
+	}
+	return user, target, true
+}
+
+// POST /users/:id/block ลบการติดตามทั้งสองทางด้วย ทำซ้ำได้
+func (h *BlockHandler) Block(c *gin.Context) {
+	user, target, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+
+	block := models.Blocks{BlockerUserID: user.ID, BlockedUserID: target.ID}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+		return tx.Where("(followerUserID = ? AND followingUserID = ?) OR (followerUserID = ? AND followingUserID = ?)",
+			user.ID, target.ID, target.ID, user.ID).
+			Delete(&models.Follows{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Success": "blocked user"})
+}
+
+// DELETE /users/:id/block การติดตามที่ถูกลบไปแล้วไม่กลับมา
+func (h *BlockHandler) Unblock(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	result := h.db.Where("blockerUserID = ? AND blockedUserID = ?", user.ID, c.Param("id")).Delete(&models.Blocks{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user is not blocked"})
+	} else {
+		c.JSON(http.StatusNoContent, gin.H{"Success": "unblocked user"})
+	}
+}
+
+// GET /users/me/blocks
+func (h *BlockHandler) ListBlocks(c *gin.Context) {
+	h.listRelated(c, &models.Blocks{}, "blockerUserID", "blockedUserID")
+}
+
+// POST /users/:id/mute ทำซ้ำได้
+func (h *BlockHandler) Mute(c *gin.Context) {
+	user, target, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+
+	mute := models.Mutes{MuterUserID: user.ID, MutedUserID: target.ID}
+	if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Success": "muted user"})
+}
+
+// DELETE /users/:id/mute
+func (h *BlockHandler) Unmute(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	result := h.db.Where("muterUserID = ? AND mutedUserID = ?", user.ID, c.Param("id")).Delete(&models.Mutes{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user is not muted"})
+	} else {
+		c.JSON(http.StatusNoContent, gin.H{"Success": "unmuted user"})
+	}
+}
+
+// GET /users/me/mutes
+func (h *BlockHandler) ListMutes(c *gin.Context) {
+	h.listRelated(c, &models.Mutes{}, "muterUserID", "mutedUserID")
+}
+
+// รายชื่อผู้ใช้ในตาราง model ที่ผู้ใช้ที่ login อยู่เป็น ownerColumn ใหม่สุดก่อน
+func (h *BlockHandler) listRelated(c *gin.Context, model interface{}, ownerColumn, targetColumn string) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	query := h.db.Model(model).Where(ownerColumn+" = ?", user.ID).Session(&gorm.Session{})
+
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []struct {
+		UserID    uint `gorm:"column:userID"`
+		CreatedAt time.Time
+	}
+	err = query.Select(targetColumn + " AS userID, created_at").
+		Order("created_at DESC").
+		Offset(pagination.Offset()).
+		Limit(pagination.PageSize).
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.UserID)
+	}
+	var users []models.Users
+	if err := h.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID := map[uint]models.Users{}
+	for _, related := range users {
+		byID[related.ID] = related
+	}
+
+	items := make([]RelatedUserResponse, 0, len(rows))
+	for _, row := range rows {
+		related, ok := byID[row.UserID]
+		if !ok {
+			continue
+		}
+		items = append(items, RelatedUserResponse{User: newUserResponse(related, user), CreatedAt: row.CreatedAt})
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
@@ -0,0 +1,151 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockAndMute(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์ แล้วเพิ่มผู้ติดตาม
+	db, owner, post := setupTestData(t)
+
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	err := db.Create(&other).Error
+	assert.NoError(t, err)
+	err = db.Create(&models.Follows{FollowingUserID: owner.ID, FollowerUserID: other.ID}).Error
+	assert.NoError(t, err)
+
+	blockHandler := handlers.NewBlockHandler(db)
+	followHandler := handlers.NewFollowHandler(db)
+	postHandler := handlers.NewPostHandler(db)
+	ownerID := strconv.FormatUint(uint64(owner.ID), 10)
+	otherID := strconv.FormatUint(uint64(other.ID), 10)
+	postID := strconv.FormatUint(uint64(post.PostID), 10)
+
+	request := func(username, method, path, id string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Request, _ = http.NewRequest(method, path, nil)
+		handle(c)
+		return w
+	}
+	feedTotal := func() int64 {
+		w := request(other.Username, "GET", "/feed", "", postHandler.Feed)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var feed struct {
+			Total int64 `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+		return feed.Total
+	}
+
+	// mute ซ่อนโพสต์จาก feed เท่านั้น ยังเปิดอ่านโพสต์ได้
+	assert.Equal(t, int64(1), feedTotal())
+	w := request(other.Username, "POST", "/users/"+ownerID+"/mute", ownerID, blockHandler.Mute)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(0), feedTotal())
+	w = request(other.Username, "GET", "/posts/"+postID, postID, postHandler.GetPost)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(other.Username, "DELETE", "/users/"+ownerID+"/mute", ownerID, blockHandler.Unmute)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, int64(1), feedTotal())
+
+	// บล็อกแล้วการติดตามถูกลบ อีกฝ่ายมองไม่เห็นโพสต์และติดตามใหม่ไม่ได้
+	w = request(owner.Username, "POST", "/users/"+otherID+"/block", otherID, blockHandler.Block)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var follows int64
+	db.Model(&models.Follows{}).Count(&follows)
+	assert.Equal(t, int64(0), follows)
+
+	w = request(other.Username, "GET", "/posts/"+postID, postID, postHandler.GetPost)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(other.Username, "POST", "/users/"+ownerID+"/follow", ownerID, followHandler.Follow)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = request(owner.Username, "GET", "/users/me/blocks", "", blockHandler.ListBlocks)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var blocks struct {
+		Items []handlers.RelatedUserResponse `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &blocks))
+	assert.Len(t, blocks.Items, 1)
+	assert.Equal(t, other.ID, blocks.Items[0].User.ID)
+
+	// เลิกบล็อกแล้วมองเห็นโพสต์อีกครั้ง
+	w = request(owner.Username, "DELETE", "/users/"+otherID+"/block", otherID, blockHandler.Unblock)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(other.Username, "GET", "/posts/"+postID, postID, postHandler.GetPost)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBlockHidesUsersAndReactions(t *testing.T) {
+	db, owner, post := setupTestData(t)
+
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	third := models.Users{Username: "mike_doe", Fullname: "Mike Doe", Email: "mike@example.com"}
+	for _, user := range []*models.Users{&other, &third} {
+		assert.NoError(t, db.Create(user).Error)
+	}
+	for _, user := range []models.Users{other, third} {
+		err := db.Create(&models.Reactions{PostID: post.PostID, UserID: user.ID, Type: "❤️"}).Error
+		assert.NoError(t, err)
+	}
+	err := db.Create(&models.Blocks{BlockerUserID: owner.ID, BlockedUserID: other.ID}).Error
+	assert.NoError(t, err)
+
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+	reactionHandler := handlers.NewReactionHandler(db)
+	request := func(username, id string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		handle(c)
+		return w
+	}
+
+	// ทั้งสองฝ่ายเปิดดูผู้ใช้อีกฝ่ายไม่ได้
+	w := request(owner.Username, strconv.FormatUint(uint64(other.ID), 10), userHandler.GetUser)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(other.Username, strconv.FormatUint(uint64(owner.ID), 10), userHandler.GetUser)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(owner.Username, strconv.FormatUint(uint64(third.ID), 10), userHandler.GetUser)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// รายชื่อผู้ใช้ไม่มีผู้ที่บล็อกกัน
+	w = request(owner.Username, "", userHandler.ListUsers)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var users []handlers.CreateUserResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &users))
+	var ids []uint
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	assert.ElementsMatch(t, []uint{owner.ID, third.ID}, ids)
+
+	// reaction ของผู้ที่บล็อกกันไม่ถูกแสดง
+	w = request(owner.Username, strconv.FormatUint(uint64(post.PostID), 10), reactionHandler.ListReactions)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var reactions struct {
+		Items []handlers.CreateReactionResponse `json:"items"`
+		Total int64                             `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &reactions))
+	assert.Equal(t, int64(1), reactions.Total)
+	if assert.Len(t, reactions.Items, 1) {
+		assert.Equal(t, third.ID, reactions.Items[0].UserID)
+	}
+}
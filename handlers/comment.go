@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CommentHandler struct {
+	db *gorm.DB
+}
+
+func NewCommentHandler(db *gorm.DB) *CommentHandler {
+	return &CommentHandler{
+		db: db,
+	}
+}
+
+type CreateCommentResponse struct {
+	ID        uint                    `json:"id"`
+	PostID    uint                    `json:"postID"`
+	UserID    uint                    `json:"userID"`
+	ParentID  *uint                   `json:"parentID"`
+	Body      string                  `json:"body"`
+	Deleted   bool                    `json:"deleted"`
+	CreatedAt time.Time               `json:"createdAt"`
+	UpdatedAt time.Time               `json:"updatedAt"`
+	Replies   []CreateCommentResponse `json:"replies,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	Body     string `json:"body" binding:"required,min=1,max=5000"`
+	ParentID *uint  `json:"parentID"`
+}
+
+type CreateCommentUpdateRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=5000"`
+}
+
+// ความคิดเห็นที่ถูกลบจะไม่แสดงเนื้อหา แต่ยังคงอยู่ใน thread
+func newCommentResponse(comment models.Comments) CreateCommentResponse {
+	response := CreateCommentResponse{
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		UserID:    comment.UserID,
+		ParentID:  comment.ParentID,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+	if comment.DeletedAt != nil {
+		response.Body = ""
+		response.Deleted = true
+	}
+	return response
+}
+
+// สร้าง tree ของความคิดเห็นจาก roots โดยใช้ความคิดเห็นทั้งหมดของโพสต์
+func buildCommentTree(roots []models.Comments, all []models.Comments) []CreateCommentResponse {
+	children := map[uint][]models.Comments{}
+	for _, comment := range all {
+		if comment.ParentID != nil {
+			children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+		}
+	}
+
+	var build func(comment models.Comments) CreateCommentResponse
+	build = func(comment models.Comments) CreateCommentResponse {
+		response := newCommentResponse(comment)
+		for _, child := range children[comment.ID] {
+			response.Replies = append(response.Replies, build(child))
+		}
+		return response
+	}
+
+	tree := []CreateCommentResponse{}
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	return tree
+}
+
+func (h *CommentHandler) findComment(c *gin.Context, postID uint) (models.Comments, bool) {
+	var comment models.Comments
+	result := h.db.Where("postID = ?", postID).First(&comment, c.Param("commentID"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return comment, false
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return comment, false
+	}
+	return comment, true
+}
+
+// GET /posts/:id/comments?view=tree|flat&page=1&pageSize=20
+// แบบ tree จะแบ่งหน้าตามความคิดเห็นระดับบนสุด แบบ flat แบ่งหน้าตามความคิดเห็นทั้งหมด
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	post, ok := findVisiblePost(c, h.db, currentUserID(c, h.db))
+	if !ok {
+		return
+	}
+
+	// ความคิดเห็นของผู้ใช้ที่บล็อกกับ viewer จะถูกซ่อน
+	viewerID := currentUserID(c, h.db)
+	pagination := parsePagination(c)
+	query := h.db.Model(&models.Comments{}).Scopes(models.NotBlocked(viewerID, "comments.userID")).Where("postID = ?", post.PostID)
+	if c.DefaultQuery("view", "tree") == "tree" {
+		query = query.Where("parentID IS NULL")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var comments []models.Comments
+	result := query.Order("created_at, id").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&comments)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if c.DefaultQuery("view", "tree") != "tree" {
+		response := []CreateCommentResponse{}
+		for _, comment := range comments {
+			response = append(response, newCommentResponse(comment))
+		}
+		c.JSON(http.StatusOK, pagination.Response(response, total))
+		return
+	}
+
+	var all []models.Comments
+	result = h.db.Scopes(models.NotBlocked(viewerID, "comments.userID")).
+		Where("postID = ? AND parentID IS NOT NULL", post.PostID).
+		Order("created_at, id").
+		Find(&all)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(buildCommentTree(comments, all), total))
+}
+
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return
+	}
+
+	var req CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ตอบกลับได้เฉพาะความคิดเห็นในโพสต์เดียวกันที่ยังไม่ถูกลบ
+	var parent models.Comments
+	if req.ParentID != nil {
+		result := h.db.Where("postID = ? AND deleted_at IS NULL", post.PostID).First(&parent, *req.ParentID)
+		if result.Error != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parent comment not found"})
+			return
+		}
+
+		blocked, err := isBlocked(h.db, user.ID, parent.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if blocked {
+			c.JSON(http.StatusForbidden, gin.H{"error": "cannot reply to this comment"})
+			return
+		}
+	}
+
+	comment := models.Comments{
+		PostID:   post.PostID,
+		UserID:   user.ID,
+		ParentID: req.ParentID,
+		Body:     req.Body,
+	}
+
+	// แจ้งเจ้าของความคิดเห็นที่ถูกตอบกลับ และเจ้าของโพสต์ถ้าเป็นคนละคนกัน
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+
+		postID, commentID := post.PostID, comment.ID
+		if req.ParentID != nil {
+			err := events.Publish(tx, events.Event{
+				Type:      models.NotificationReply,
+				UserID:    parent.UserID,
+				ActorID:   user.ID,
+				PostID:    &postID,
+				CommentID: &commentID,
+			})
+			if err != nil || parent.UserID == post.UserID {
+				return err
+			}
+		}
+		return events.Publish(tx, events.Event{
+			Type:      models.NotificationComment,
+			UserID:    post.UserID,
+			ActorID:   user.ID,
+			PostID:    &postID,
+			CommentID: &commentID,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newCommentResponse(comment))
+}
+
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return
+	}
+
+	var req CreateCommentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, ok := h.findComment(c, post.PostID)
+	if !ok {
+		return
+	}
+
+	if comment.UserID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can edit a comment"})
+		return
+	}
+
+	if comment.DeletedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "comment has been deleted"})
+		return
+	}
+
+	result := h.db.Model(&comment).Update("body", req.Body)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newCommentResponse(comment))
+}
+
+// ลบแบบ soft delete เพื่อให้ความคิดเห็นที่ตอบกลับยังอยู่ใน thread เดิม
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.findComment(c, post.PostID)
+	if !ok {
+		return
+	}
+
+	if comment.UserID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can delete a comment"})
+		return
+	}
+
+	if comment.DeletedAt == nil {
+		result := h.db.Model(&comment).Update("deleted_at", time.Now())
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{"Success": "removed record"})
+}
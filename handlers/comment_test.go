@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateComment(t *testing.T) {
+	db, user, post := setupTestData(t)
+	commentHandler := handlers.NewCommentHandler(db)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+	c.Request, _ = http.NewRequest("POST", "/posts/1/comments", bytes.NewReader([]byte(`{"body": "first comment"}`)))
+	commentHandler.CreateComment(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response handlers.CreateCommentResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, post.PostID, response.PostID)
+	assert.Equal(t, user.ID, response.UserID)
+	assert.Equal(t, "first comment", response.Body)
+	assert.Nil(t, response.ParentID)
+}
+
+func TestDeleteCommentKeepsThread(t *testing.T) {
+	db, user, post := setupTestData(t)
+	commentHandler := handlers.NewCommentHandler(db)
+
+	// ความคิดเห็นหลักและความคิดเห็นที่ตอบกลับ
+	parent := models.Comments{PostID: post.PostID, UserID: user.ID, Body: "parent"}
+	err := db.Create(&parent).Error
+	assert.NoError(t, err)
+	reply := models.Comments{PostID: post.PostID, UserID: user.ID, ParentID: &parent.ID, Body: "reply"}
+	err = db.Create(&reply).Error
+	assert.NoError(t, err)
+
+	// ลบความคิดเห็นหลัก
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params,
+		gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)},
+		gin.Param{Key: "commentID", Value: strconv.FormatUint(uint64(parent.ID), 10)},
+	)
+	c.Request, _ = http.NewRequest("DELETE", "/posts/1/comments/1", nil)
+	commentHandler.DeleteComment(c)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// thread ยังอยู่ครบ แต่ความคิดเห็นหลักไม่แสดงเนื้อหา
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+	c.Request, _ = http.NewRequest("GET", "/posts/1/comments?view=tree", nil)
+	commentHandler.ListComments(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Items []handlers.CreateCommentResponse `json:"items"`
+		Total int64                            `json:"total"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), response.Total)
+	assert.Len(t, response.Items, 1)
+	assert.True(t, response.Items[0].Deleted)
+	assert.Equal(t, "", response.Items[0].Body)
+	assert.Len(t, response.Items[0].Replies, 1)
+	assert.Equal(t, "reply", response.Items[0].Replies[0].Body)
+}
+
+func TestUpdateCommentOnlyAuthor(t *testing.T) {
+	db, user, post := setupTestData(t)
+	commentHandler := handlers.NewCommentHandler(db)
+
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	err := db.Create(&other).Error
+	assert.NoError(t, err)
+
+	comment := models.Comments{PostID: post.PostID, UserID: user.ID, Body: "original"}
+	err = db.Create(&comment).Error
+	assert.NoError(t, err)
+
+	// ผู้ใช้อื่นแก้ไขความคิดเห็นไม่ได้
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", other.Username)
+	c.Params = append(c.Params,
+		gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)},
+		gin.Param{Key: "commentID", Value: strconv.FormatUint(uint64(comment.ID), 10)},
+	)
+	c.Request, _ = http.NewRequest("PUT", "/posts/1/comments/1", bytes.NewReader([]byte(`{"body": "edited"}`)))
+	commentHandler.UpdateComment(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var stored models.Comments
+	err = db.First(&stored, comment.ID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "original", stored.Body)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ดึง user ที่ login อยู่ จาก username ที่ JWTMiddleware set ไว้ใน context
+func currentUser(c *gin.Context, db *gorm.DB) (models.Users, error) {
+	var user models.Users
+	username := c.GetString("username")
+	if username == "" {
+		return user, gorm.ErrRecordNotFound
+	}
+
+	result := db.Where("username = ?", username).First(&user)
+	return user, result.Error
+}
+
+// คืน id ของ user ที่ login อยู่ ถ้าไม่ได้ login จะได้ 0
+func currentUserID(c *gin.Context, db *gorm.DB) uint {
+	user, err := currentUser(c, db)
+	if err != nil {
+		return 0
+	}
+	return user.ID
+}
+
+// ตรวจว่าผู้ใช้ที่ login เป็น admin ถ้าไม่ใช่จะตอบ error และคืน false
+func requireAdmin(c *gin.Context, db *gorm.DB) (models.Users, bool) {
+	user, err := currentUser(c, db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return user, false
+	}
+	if !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return user, false
+	}
+	return user, true
+}
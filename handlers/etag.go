@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errVersionConflict คือข้อมูลถูกแก้ไปก่อนระหว่างที่กำลังบันทึก
+var errVersionConflict = errors.New("resource has been modified")
+
+// ETag ของข้อมูล สร้างจาก id และ version ที่เพิ่มขึ้นทุกครั้งที่แก้ไข
+func entityTag(kind string, id, version uint) string {
+	return fmt.Sprintf(`"%s-%d-%d"`, kind, id, version)
+}
+
+func postETag(postID, version uint) string {
+	return entityTag("post", postID, version)
+}
+
+func userETag(userID, version uint) string {
+	return entityTag("user", userID, version)
+}
+
+// ตั้ง REQUIRE_IF_MATCH=true เพื่อบังคับให้ส่ง If-Match ทุกครั้งที่แก้ไขหรือลบ
+func requireIfMatch() bool {
+	return os.Getenv("REQUIRE_IF_MATCH") == "true"
+}
+
+// แยกรายการ ETag ใน header ถ้า weak จะตัด W/ ออก
+func parseETags(header string, weak bool) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ตรวจ If-Match กับ ETag ปัจจุบัน ถ้าไม่ผ่านจะตอบ 412 (หรือ 428 ถ้าบังคับแต่ไม่ส่งมา) และคืน false
+func checkIfMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if requireIfMatch() {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+			return false
+		}
+		return true
+	}
+
+	// If-Match เทียบแบบ strong ETag แบบ weak จะไม่ตรงเสมอ
+	for _, tag := range parseETags(header, false) {
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	c.Header("ETag", etag)
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": errVersionConflict.Error()})
+	return false
+}
+
+// ตั้ง ETag ใน response ถ้า If-None-Match ตรงจะตอบ 304 และคืน true
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	for _, tag := range parseETags(c.GetHeader("If-None-Match"), true) {
+		if tag == "*" || tag == etag {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// เขียน error ของการบันทึก ถ้า version ไม่ตรงตอบ 412
+func respondSaveError(c *gin.Context, err error) {
+	if errors.Is(err, errVersionConflict) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// แก้ไขแถวเฉพาะเมื่อ version ยังเท่ากับที่อ่านมา แล้วเพิ่ม version
+func updateVersioned(tx *gorm.DB, model interface{}, version uint, values map[string]interface{}) error {
+	values["version"] = gorm.Expr("version + 1")
+	result := tx.Model(model).Where("version = ?", version).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	return nil
+}
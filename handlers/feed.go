@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /feed โพสต์ที่ published แล้วของตัวเองและคนที่ติดตาม เรียงจากใหม่ไปเก่า
+// กรองสิทธิ์การมองเห็นด้วยเงื่อนไขเดียวกับ ListPosts และตัดโพสต์ของผู้ใช้ที่ mute ไว้
+func (h *PostHandler) Feed(c *gin.Context) {
+	viewerID := currentUserID(c, h.db)
+	if viewerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	following := h.db.Model(&models.Follows{}).Select("followingUserID").Where("followerUserID = ? AND status = ?", viewerID, models.FollowStatusAccepted)
+	muted := h.db.Model(&models.Mutes{}).Select("mutedUserID").Where("muterUserID = ?", viewerID)
+	query := h.db.Model(&models.Posts{}).
+		Scopes(models.VisiblePosts(viewerID)).
+		Where("posts.status = ?", models.PostStatusPublished).
+		Where("posts.userID = ? OR posts.userID IN (?)", viewerID, following).
+		Where("posts.userID NOT IN (?)", muted).
+		Session(&gorm.Session{})
+
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var posts []models.Posts
+	err := query.Order("posts.publish_at DESC, posts.postID DESC").
+		Offset(pagination.Offset()).
+		Limit(pagination.PageSize).
+		Find(&posts).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := postResponses(h.db, posts, viewerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(response, total))
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FollowHandler struct {
+	db *gorm.DB
+}
+
+func NewFollowHandler(db *gorm.DB) *FollowHandler {
+	return &FollowHandler{
+		db: db,
+	}
+}
+
+type FollowResponse struct {
+	FollowerUserID  uint       `json:"followerUserID"`
+	FollowingUserID uint       `json:"followingUserID"`
+	Status          string     `json:"status"`
+	AcceptedAt      *time.Time `json:"acceptedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// คำขอติดตามที่รออนุมัติ พร้อมข้อมูลผู้ขอ
+type FollowRequestResponse struct {
+	User      CreateUserResponse `json:"user"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+func newFollowResponse(follow models.Follows) FollowResponse {
+	return FollowResponse{
+		FollowerUserID:  follow.FollowerUserID,
+		FollowingUserID: follow.FollowingUserID,
+		Status:          follow.Status,
+		AcceptedAt:      follow.AcceptedAt,
+		CreatedAt:       follow.CreatedAt,
+	}
+}
+
+// POST /users/:id/follow ถ้าเป้าหมายเป็นบัญชี private จะเป็นคำขอที่รออนุมัติและตอบ 202
+// ถ้าติดตามหรือขอไว้แล้วจะคืนสถานะเดิม
+func (h *FollowHandler) Follow(c *gin.Context) {
+	follower, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	var target models.Users
+	result := h.db.First(&target, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if target.ID == follower.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot follow yourself"})
+		return
+	}
+
+	blocked, err := isBlocked(h.db, follower.ID, target.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot follow this user"})
+		return
+	}
+
+	var follow models.Follows
+	result = h.db.Where("followerUserID = ? AND followingUserID = ?", follower.ID, target.ID).First(&follow)
+	if result.Error == nil {
+		c.JSON(http.StatusOK, newFollowResponse(follow))
+		return
+	}
+	if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	now := time.Now()
+	status := http.StatusCreated
+	follow = models.Follows{
+		FollowingUserID: target.ID,
+		FollowerUserID:  follower.ID,
+		Status:          models.FollowStatusAccepted,
+		AcceptedAt:      &now,
+	}
+	event := events.Event{Type: models.NotificationFollow, UserID: target.ID, ActorID: follower.ID}
+	if target.Private {
+		status = http.StatusAccepted
+		follow.Status = models.FollowStatusPending
+		follow.AcceptedAt = nil
+		event.Type = models.NotificationFollowRequest
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&follow).Error; err != nil {
+			return err
+		}
+		if err := events.Publish(tx, events.Event{Type: events.FollowCreated, ActorID: follower.ID, UserID: target.ID}); err != nil {
+			return err
+		}
+		return events.Publish(tx, event)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(status, newFollowResponse(follow))
+}
+
+// DELETE /users/:id/follow เลิกติดตามหรือยกเลิกคำขอที่ยังไม่ได้อนุมัติ
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	follower, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	result := h.db.Where("followerUserID = ? AND followingUserID = ?", follower.ID, c.Param("id")).Delete(&models.Follows{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not following this user"})
+	} else {
+		c.JSON(http.StatusNoContent, gin.H{"Success": "unfollowed user"})
+	}
+}
+
+// GET /users/me/follow-requests คำขอติดตามที่รออนุมัติ ใหม่สุดก่อน
+func (h *FollowHandler) ListRequests(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	query := h.db.Model(&models.Follows{}).
+		Where("followingUserID = ? AND status = ?", user.ID, models.FollowStatusPending).
+		Session(&gorm.Session{})
+
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var follows []models.Follows
+	err = query.Order("created_at DESC").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&follows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]uint, 0, len(follows))
+	for _, follow := range follows {
+		ids = append(ids, follow.FollowerUserID)
+	}
+	var users []models.Users
+	if err := h.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID := map[uint]models.Users{}
+	for _, requester := range users {
+		byID[requester.ID] = requester
+	}
+
+	items := make([]FollowRequestResponse, 0, len(follows))
+	for _, follow := range follows {
+		requester, ok := byID[follow.FollowerUserID]
+		if !ok {
+			continue
+		}
+		items = append(items, FollowRequestResponse{User: newUserResponse(requester, user), CreatedAt: follow.CreatedAt})
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
+
+// POST /users/me/follow-requests/:followerID/approve
+func (h *FollowHandler) ApproveRequest(c *gin.Context) {
+	user, followerID, ok := h.requestParams(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	var follow models.Follows
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Follows{}).
+			Where("followingUserID = ? AND followerUserID = ? AND status = ?", user.ID, followerID, models.FollowStatusPending).
+			Updates(map[string]interface{}{"status": models.FollowStatusAccepted, "accepted_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err := events.Publish(tx, events.Event{
+			Type:    models.NotificationFollowAccepted,
+			UserID:  followerID,
+			ActorID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Where("followingUserID = ? AND followerUserID = ?", user.ID, followerID).First(&follow).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "follow request not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newFollowResponse(follow))
+}
+
+// POST /users/me/follow-requests/:followerID/reject ผู้ขอไม่ได้รับแจ้งเตือน
+func (h *FollowHandler) RejectRequest(c *gin.Context) {
+	user, followerID, ok := h.requestParams(c)
+	if !ok {
+		return
+	}
+
+	result := h.db.Where("followingUserID = ? AND followerUserID = ? AND status = ?", user.ID, followerID, models.FollowStatusPending).
+		Delete(&models.Follows{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "follow request not found"})
+	} else {
+		c.JSON(http.StatusNoContent, gin.H{"Success": "rejected follow request"})
+	}
+}
+
+// อ่านผู้ใช้ที่ login อยู่และ followerID จาก path ถ้าผิดจะตอบ error และคืน false
+func (h *FollowHandler) requestParams(c *gin.Context) (models.Users, uint, bool) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return user, 0, false
+	}
+
+	followerID, err := strconv.ParseUint(c.Param("followerID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid followerID"})
+		return user, 0, false
+	}
+	return user, uint(followerID), true
+}
+
+// อนุมัติคำขอที่ค้างอยู่ทั้งหมด ใช้ตอนเปลี่ยนบัญชีจาก private เป็น public
+func acceptPendingFollows(tx *gorm.DB, userID uint, now time.Time) error {
+	return tx.Model(&models.Follows{}).
+		Where("followingUserID = ? AND status = ?", userID, models.FollowStatusPending).
+		Updates(map[string]interface{}{"status": models.FollowStatusAccepted, "accepted_at": now}).Error
+}
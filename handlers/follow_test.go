@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowPrivateAccount(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์ แล้วทำให้เจ้าของโพสต์เป็นบัญชี private
+	db, owner, post := setupTestData(t)
+	err := db.Model(&owner).Update("private", true).Error
+	assert.NoError(t, err)
+
+	follower := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	err = db.Create(&follower).Error
+	assert.NoError(t, err)
+
+	followHandler := handlers.NewFollowHandler(db)
+	postHandler := handlers.NewPostHandler(db)
+	ownerID := strconv.FormatUint(uint64(owner.ID), 10)
+	followerID := strconv.FormatUint(uint64(follower.ID), 10)
+
+	request := func(username, method, path string, params gin.Params, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = params
+		c.Request, _ = http.NewRequest(method, path, nil)
+		handle(c)
+		return w
+	}
+	getPost := func() int {
+		params := gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)}}
+		return request(follower.Username, "GET", "/posts/"+params[0].Value, params, postHandler.GetPost).Code
+	}
+
+	// ติดตามบัญชี private ได้เป็นคำขอที่รออนุมัติ และยังมองไม่เห็นโพสต์
+	w := request(follower.Username, "POST", "/users/"+ownerID+"/follow", gin.Params{{Key: "id", Value: ownerID}}, followHandler.Follow)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var follow handlers.FollowResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &follow))
+	assert.Equal(t, models.FollowStatusPending, follow.Status)
+	assert.Equal(t, http.StatusNotFound, getPost())
+
+	var notifications int64
+	db.Model(&models.Notifications{}).Where("userID = ? AND type = ?", owner.ID, models.NotificationFollowRequest).Count(&notifications)
+	assert.Equal(t, int64(1), notifications)
+
+	w = request(owner.Username, "GET", "/users/me/follow-requests", nil, followHandler.ListRequests)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var requests struct {
+		Items []handlers.FollowRequestResponse `json:"items"`
+		Total int64                            `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &requests))
+	assert.Equal(t, int64(1), requests.Total)
+	assert.Equal(t, follower.ID, requests.Items[0].User.ID)
+
+	// อนุมัติแล้วผู้ติดตามเห็นโพสต์และได้รับแจ้งเตือน
+	params := gin.Params{{Key: "followerID", Value: followerID}}
+	w = request(owner.Username, "POST", "/users/me/follow-requests/"+followerID+"/approve", params, followHandler.ApproveRequest)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, getPost())
+
+	db.Model(&models.Notifications{}).Where("userID = ? AND type = ?", follower.ID, models.NotificationFollowAccepted).Count(&notifications)
+	assert.Equal(t, int64(1), notifications)
+
+	// ไม่มีคำขอค้างแล้ว ปฏิเสธซ้ำไม่ได้
+	w = request(owner.Username, "POST", "/users/me/follow-requests/"+followerID+"/reject", params, followHandler.RejectRequest)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMakePublicOnlyOwner(t *testing.T) {
+	db, owner, _ := setupTestData(t)
+	err := db.Model(&owner).Update("private", true).Error
+	assert.NoError(t, err)
+
+	follower := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	admin := models.Users{Username: "admin_user", Fullname: "Admin User", Email: "admin@example.com", Role: models.RoleAdmin}
+	for _, user := range []*models.Users{&follower, &admin} {
+		assert.NoError(t, db.Create(user).Error)
+	}
+	err = db.Create(&models.Follows{FollowerUserID: follower.ID, FollowingUserID: owner.ID, Status: models.FollowStatusPending}).Error
+	assert.NoError(t, err)
+
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+	makePublic := func(username string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(owner.ID), 10)}}
+		c.Request, _ = http.NewRequest("PATCH", "/users/"+c.Params[0].Value, bytes.NewReader([]byte(`{"private": false}`)))
+		c.Request.Header.Set("Content-Type", "application/merge-patch+json")
+		userHandler.UpdateUser(c)
+		return w.Code
+	}
+	followStatus := func() string {
+		var follow models.Follows
+		err := db.Where("followerUserID = ? AND followingUserID = ?", follower.ID, owner.ID).First(&follow).Error
+		assert.NoError(t, err)
+		return follow.Status
+	}
+
+	// ผู้ใช้อื่นและผู้ดูแลระบบเปิดบัญชีเป็น public แทนเจ้าของไม่ได้ คำขอติดตามยังค้างอยู่
+	assert.Equal(t, http.StatusForbidden, makePublic(follower.Username))
+	assert.Equal(t, http.StatusForbidden, makePublic(admin.Username))
+	assert.Equal(t, models.FollowStatusPending, followStatus())
+
+	var updated models.Users
+	assert.NoError(t, db.First(&updated, owner.ID).Error)
+	assert.True(t, updated.Private)
+
+	// เจ้าของเปิดเองได้ และคำขอที่ค้างถูกอนุมัติ
+	assert.Equal(t, http.StatusOK, makePublic(owner.Username))
+	assert.Equal(t, models.FollowStatusAccepted, followStatus())
+}
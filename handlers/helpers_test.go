@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/NopparootSuree/go-social/fixtures"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ลบตารางผู้ใช้ สำหรับการทดสอบที่ใช้เฉพาะ UserHandler
+func teardownTestDB(db *gorm.DB) {
+	db.Migrator().DropTable(&models.Users{}, &models.AuditLogs{})
+	db.Exec("DELETE FROM products")
+}
+
+// ตารางที่ handler ใช้งาน
+var testTables = []interface{}{
+	&models.Posts{},
+	&models.PostRevisions{},
+	&models.Comments{},
+	&models.Reactions{},
+	&models.Attachments{},
+	&models.Tags{},
+	&models.PostTags{},
+	&models.Mentions{},
+	&models.Notifications{},
+	&models.NotificationPreferences{},
+	&models.Follows{},
+	&models.Blocks{},
+	&models.Mutes{},
+	&models.WebhookSubscriptions{},
+	&models.WebhookDeliveries{},
+	&models.OutboxEvents{},
+	&models.Jobs{},
+	&models.JobSchedules{},
+	&models.AuditLogs{},
+	&models.Users{},
+}
+
+// ลบทุกตารางใน testTables
+func teardownTestDBs(db *gorm.DB) {
+	db.Migrator().DropTable(testTables...)
+	db.Exec("DELETE FROM products")
+}
+
+// เตรียมฐานข้อมูลที่มีทุกตาราง พร้อมผู้ใช้หนึ่งคนและโพสต์ published หนึ่งโพสต์ของผู้ใช้นั้น
+func setupTestData(t *testing.T) (*gorm.DB, models.Users, models.Posts) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	user := models.Users{
+		Username: "john_doe",
+		Fullname: "John Doe",
+		Email:    "john@example.com",
+	}
+	err = db.Create(&user).Error
+	assert.NoError(t, err)
+
+	post := models.Posts{
+		Title:  "title123",
+		Body:   "body123",
+		UserID: user.ID,
+		Status: "published",
+	}
+	err = db.Create(&post).Error
+	assert.NoError(t, err)
+
+	return db, user, post
+}
+
+// บันทึกข้อมูลที่สร้างจาก fixtures สำหรับการทดสอบที่ต้องการข้อมูลจำนวนมาก ID ใน data ที่คืนเป็นค่าจริงในฐานข้อมูล
+func loadFixtures(t *testing.T, db *gorm.DB, cfg fixtures.Config) *fixtures.Dataset {
+	data, err := fixtures.Generate(cfg)
+	if err != nil {
+		t.Fatalf("generate fixtures: %v", err)
+	}
+	if err := fixtures.Insert(db, data, 100); err != nil {
+		t.Fatalf("insert fixtures: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type JobHandler struct {
+	db *gorm.DB
+}
+
+func NewJobHandler(db *gorm.DB) *JobHandler {
+	return &JobHandler{
+		db: db,
+	}
+}
+
+type JobResponse struct {
+	ID          uint       `json:"id"`
+	Queue       string     `json:"queue"`
+	Type        string     `json:"type"`
+	Payload     string     `json:"payload"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	RunAt       time.Time  `json:"runAt"`
+	LockedBy    string     `json:"lockedBy"`
+	LockedUntil *time.Time `json:"lockedUntil"`
+	LastError   string     `json:"lastError"`
+	CompletedAt *time.Time `json:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// จำนวน job ของแต่ละ queue แยกตามสถานะ
+type JobStatsResponse struct {
+	Queue  string `json:"queue"`
+	Status string `json:"status"`
+	Total  int64  `json:"total"`
+}
+
+func newJobResponse(job models.Jobs) JobResponse {
+	return JobResponse{
+		ID:          job.ID,
+		Queue:       job.Queue,
+		Type:        job.Type,
+		Payload:     job.Payload,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		LockedBy:    job.LockedBy,
+		LockedUntil: job.LockedUntil,
+		LastError:   job.LastError,
+		CompletedAt: job.CompletedAt,
+		CreatedAt:   job.CreatedAt,
+	}
+}
+
+// หา job จาก :id ถ้าไม่เจอจะตอบ error และคืน false
+func (h *JobHandler) job(c *gin.Context) (models.Jobs, bool) {
+	var job models.Jobs
+	result := h.db.First(&job, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return job, false
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return job, false
+	}
+	return job, true
+}
+
+// GET /jobs?queue=&status=&type= งานล่าสุดก่อน
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.db); !ok {
+		return
+	}
+
+	query := h.db.Model(&models.Jobs{})
+	for _, column := range []string{"queue", "status", "type"} {
+		if value := c.Query(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	query = query.Session(&gorm.Session{})
+
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var jobs []models.Jobs
+	err := query.Order("id DESC").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&jobs).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := []JobResponse{}
+	for _, job := range jobs {
+		items = append(items, newJobResponse(job))
+	}
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
+
+// GET /jobs/stats
+func (h *JobHandler) Stats(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.db); !ok {
+		return
+	}
+
+	stats := []JobStatsResponse{}
+	err := h.db.Model(&models.Jobs{}).
+		Select("queue, status, COUNT(*) AS total").
+		Group("queue, status").
+		Order("queue, status").
+		Scan(&stats).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GET /jobs/:id
+func (h *JobHandler) GetJob(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.db); !ok {
+		return
+	}
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newJobResponse(job))
+}
+
+// POST /jobs/:id/retry สั่งทำ job ที่ dead หรือที่ยังรออยู่ใหม่ทันที จำนวนครั้งเริ่มนับใหม่
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	admin, ok := requireAdmin(c, h.db)
+	if !ok {
+		return
+	}
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+
+	var result *gorm.DB
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result = tx.Model(&job).
+			Where("status IN ?", []string{models.JobStatusDead, models.JobStatusPending}).
+			Updates(map[string]interface{}{
+				"status":       models.JobStatusPending,
+				"attempts":     0,
+				"run_at":       time.Now(),
+				"completed_at": nil,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return audit.Record(tx, auditActor(c, admin), audit.Entry{
+			Action:     audit.ActionJobRetry,
+			TargetType: audit.TargetJob,
+			TargetID:   job.ID,
+			Before:     map[string]interface{}{"status": job.Status, "attempts": job.Attempts},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "only dead or pending jobs can be retried"})
+		return
+	}
+
+	h.db.First(&job, job.ID)
+	c.JSON(http.StatusAccepted, newJobResponse(job))
+}
+
+// DELETE /jobs/:id ลบ job ที่ไม่ได้กำลังทำอยู่
+func (h *JobHandler) DeleteJob(c *gin.Context) {
+	admin, ok := requireAdmin(c, h.db)
+	if !ok {
+		return
+	}
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+
+	var result *gorm.DB
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result = tx.Where("status <> ?", models.JobStatusRunning).Delete(&job)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return audit.Record(tx, auditActor(c, admin), audit.Entry{
+			Action:     audit.ActionJobDelete,
+			TargetType: audit.TargetJob,
+			TargetID:   job.ID,
+			Before:     map[string]interface{}{"queue": job.Queue, "type": job.Type, "status": job.Status},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "job is running"})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{"Success": "deleted job"})
+}
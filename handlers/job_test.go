@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/jobs"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/benbjohnson/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type greetPayload struct {
+	Name string `json:"name"`
+}
+
+func TestJobQueue(t *testing.T) {
+	db, admin, _ := setupTestData(t)
+	assert.NoError(t, db.Model(&admin).Update("role", models.RoleAdmin).Error)
+
+	mock := clock.NewMock()
+	mock.Set(time.Now().Add(time.Second))
+	runner := jobs.NewRunner(db, time.Second, time.Minute, time.Minute, time.Second).WithClock(mock)
+
+	// handler ล้มเหลวจนกว่าจะสั่งให้สำเร็จ
+	failing := true
+	var greeted []string
+	jobs.Handle(runner, "greet", func(ctx context.Context, payload greetPayload) error {
+		if failing {
+			return errors.New("mail server down")
+		}
+		greeted = append(greeted, payload.Name)
+		return nil
+	})
+
+	job, err := jobs.Enqueue(db, "greet", greetPayload{Name: "john"}, jobs.Options{MaxAttempts: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, jobs.DefaultQueue, job.Queue)
+	// job ที่ตั้งเวลาไว้ในอนาคตยังไม่ถูกทำ
+	_, err = jobs.Enqueue(db, "greet", greetPayload{Name: "later"}, jobs.Options{RunAt: mock.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+
+	// ทำไม่สำเร็จครั้งแรกรอ backoff ครั้งที่สองครบจำนวนครั้งจึงย้ายไป dead
+	n, err := runner.Work(context.Background(), jobs.DefaultQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	db.First(&job, job.ID)
+	assert.Equal(t, models.JobStatusPending, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "mail server down", job.LastError)
+
+	n, _ = runner.Work(context.Background(), jobs.DefaultQueue)
+	assert.Equal(t, 0, n)
+
+	mock.Add(2 * time.Minute)
+	runner.Work(context.Background(), jobs.DefaultQueue)
+	db.First(&job, job.ID)
+	assert.Equal(t, models.JobStatusDead, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+
+	request := func(method, path string, params gin.Params, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", admin.Username)
+		c.Params = params
+		c.Request, _ = http.NewRequest(method, path, nil)
+		handle(c)
+		return w
+	}
+	jobHandler := handlers.NewJobHandler(db)
+	jobID := strconv.FormatUint(uint64(job.ID), 10)
+
+	var list struct {
+		Items []handlers.JobResponse `json:"items"`
+		Total int64                  `json:"total"`
+	}
+	w := request("GET", "/jobs?status=dead", nil, jobHandler.ListJobs)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, int64(1), list.Total)
+	assert.Equal(t, job.ID, list.Items[0].ID)
+
+	// admin สั่งทำใหม่ job จาก dead กลับไปรอทำ
+	failing = false
+	w = request("POST", "/jobs/"+jobID+"/retry", gin.Params{{Key: "id", Value: jobID}}, jobHandler.RetryJob)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	mock.Add(time.Minute)
+	n, err = runner.Work(context.Background(), jobs.DefaultQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"john"}, greeted)
+	db.First(&job, job.ID)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+
+	w = request("POST", "/jobs/"+jobID+"/retry", gin.Params{{Key: "id", Value: jobID}}, jobHandler.RetryJob)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// cron สร้าง job เมื่อถึงเวลา ครั้งเดียวต่อรอบแม้เรียกซ้ำ
+	assert.NoError(t, runner.Cron("nightly-greet", "0 3 * * *", "greet", greetPayload{Name: "cron"}, jobs.Options{}))
+	n, err = runner.EnqueueDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	mock.Add(24 * time.Hour)
+	n, _ = runner.EnqueueDue(context.Background())
+	assert.Equal(t, 1, n)
+	n, _ = runner.EnqueueDue(context.Background())
+	assert.Equal(t, 0, n)
+
+	var stats []handlers.JobStatsResponse
+	w = request("GET", "/jobs/stats", nil, jobHandler.Stats)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, []handlers.JobStatsResponse{
+		{Queue: jobs.DefaultQueue, Status: models.JobStatusPending, Total: 2},
+		{Queue: jobs.DefaultQueue, Status: models.JobStatusSucceeded, Total: 1},
+	}, stats)
+}
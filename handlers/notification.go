@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ข้อความของการแจ้งเตือนแต่ละชนิด ต่อท้ายชื่อผู้กระทำ
+var notificationVerbs = map[string]string{
+	models.NotificationMention:        "mentioned you in a post",
+	models.NotificationFollow:         "followed you",
+	models.NotificationFollowRequest:  "requested to follow you",
+	models.NotificationFollowAccepted: "accepted your follow request",
+	models.NotificationComment:        "commented on your post",
+	models.NotificationReply:          "replied to your comment",
+	models.NotificationReaction:       "reacted to your post",
+}
+
+// จำนวนผู้กระทำที่แสดงชื่อในการแจ้งเตือนแบบกลุ่ม
+const groupActorLimit = 2
+
+type NotificationHandler struct {
+	db *gorm.DB
+}
+
+func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
+	return &NotificationHandler{
+		db: db,
+	}
+}
+
+type NotificationResponse struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	Actor     MentionResponse `json:"actor"`
+	PostID    *uint           `json:"postID"`
+	CommentID *uint           `json:"commentID"`
+	Message   string          `json:"message"`
+	Read      bool            `json:"read"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// การแจ้งเตือนชนิดเดียวกันบนโพสต์เดียวกันรวมเป็นรายการเดียว เช่น "X and 5 others reacted to your post"
+type NotificationGroupResponse struct {
+	Type        string            `json:"type"`
+	PostID      *uint             `json:"postID"`
+	Actors      []MentionResponse `json:"actors"`
+	ActorCount  int64             `json:"actorCount"`
+	UnreadCount int64             `json:"unreadCount"`
+	IDs         []uint            `json:"ids"`
+	Message     string            `json:"message"`
+	LatestAt    time.Time         `json:"latestAt"`
+}
+
+type MarkNotificationsReadRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// ข้อความแจ้งเตือนจากชื่อผู้กระทำล่าสุดและจำนวนผู้กระทำทั้งหมด
+func notificationMessage(notificationType string, actors []MentionResponse, actorCount int64) string {
+	verb := notificationVerbs[notificationType]
+	switch {
+	case len(actors) == 0:
+		return verb
+	case actorCount == 1:
+		return fmt.Sprintf("%s %s", actors[0].Username, verb)
+	case actorCount == 2 && len(actors) == 2:
+		return fmt.Sprintf("%s and %s %s", actors[0].Username, actors[1].Username, verb)
+	default:
+		return fmt.Sprintf("%s and %d others %s", actors[0].Username, actorCount-1, verb)
+	}
+}
+
+// ชื่อผู้ใช้ตาม id ผู้ใช้ที่ถูกลบไปแล้วจะไม่มีใน map
+func usernames(db *gorm.DB, ids []uint) (map[uint]string, error) {
+	names := map[uint]string{}
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	var users []models.Users
+	if err := db.Select("id, username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+	return names, nil
+}
+
+// GET /notifications?unread=true&grouped=false ค่าเริ่มต้นรวมเป็นกลุ่ม ใหม่สุดก่อน
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	// การแจ้งเตือนจากผู้ใช้ที่บล็อกกันภายหลังจะถูกซ่อน
+	query := h.db.Model(&models.Notifications{}).
+		Scopes(models.NotBlocked(user.ID, "notifications.actorID")).
+		Where("userID = ?", user.ID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	query = query.Session(&gorm.Session{})
+
+	if c.DefaultQuery("grouped", "true") == "false" {
+		h.listFlat(c, query)
+		return
+	}
+	h.listGrouped(c, query)
+}
+
+func (h *NotificationHandler) listFlat(c *gin.Context, query *gorm.DB) {
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var notifications []models.Notifications
+	err := query.Order("created_at DESC, id DESC").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&notifications).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorIDs := make([]uint, 0, len(notifications))
+	for _, notification := range notifications {
+		actorIDs = append(actorIDs, notification.ActorID)
+	}
+	names, err := usernames(h.db, actorIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		actor := MentionResponse{UserID: notification.ActorID, Username: names[notification.ActorID]}
+		items = append(items, NotificationResponse{
+			ID:        notification.ID,
+			Type:      notification.Type,
+			Actor:     actor,
+			PostID:    notification.PostID,
+			CommentID: notification.CommentID,
+			Message:   notificationMessage(notification.Type, []MentionResponse{actor}, 1),
+			Read:      notification.ReadAt != nil,
+			CreatedAt: notification.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
+
+// แบ่งหน้าตามกลุ่ม (ชนิด, โพสต์) แล้วอ่านการแจ้งเตือนของกลุ่มในหน้านั้น
+func (h *NotificationHandler) listGrouped(c *gin.Context, query *gorm.DB) {
+	pagination := parsePagination(c)
+	var total int64
+	err := h.db.Table("(?) AS grouped", query.Select("type").Group("type, postID")).Count(&total).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var groups []struct {
+		Type     string
+		PostID   *uint `gorm:"column:postID"`
+		LatestAt time.Time
+		Unread   int64
+	}
+	err = query.
+		Select("type, postID, MAX(created_at) AS latest_at, SUM(CASE WHEN read_at IS NULL THEN 1 ELSE 0 END) AS unread").
+		Group("type, postID").
+		Order("latest_at DESC").
+		Offset(pagination.Offset()).
+		Limit(pagination.PageSize).
+		Scan(&groups).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]NotificationGroupResponse, 0, len(groups))
+	groupActors := make([][]uint, 0, len(groups))
+	var actorIDs []uint
+	for _, group := range groups {
+		members := query.Where("type = ?", group.Type)
+		if group.PostID == nil {
+			members = members.Where("postID IS NULL")
+		} else {
+			members = members.Where("postID = ?", *group.PostID)
+		}
+
+		var notifications []models.Notifications
+		if err := members.Order("created_at DESC, id DESC").Find(&notifications).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// ผู้กระทำไม่ซ้ำ เรียงจากล่าสุด
+		item := NotificationGroupResponse{
+			Type:        group.Type,
+			PostID:      group.PostID,
+			UnreadCount: group.Unread,
+			IDs:         make([]uint, 0, len(notifications)),
+			LatestAt:    group.LatestAt,
+		}
+		seen := map[uint]bool{}
+		var actors []uint
+		for _, notification := range notifications {
+			item.IDs = append(item.IDs, notification.ID)
+			if !seen[notification.ActorID] {
+				seen[notification.ActorID] = true
+				actors = append(actors, notification.ActorID)
+			}
+		}
+		item.ActorCount = int64(len(actors))
+		if len(actors) > groupActorLimit {
+			actors = actors[:groupActorLimit]
+		}
+		actorIDs = append(actorIDs, actors...)
+		groupActors = append(groupActors, actors)
+		items = append(items, item)
+	}
+
+	names, err := usernames(h.db, actorIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range items {
+		items[i].Actors = make([]MentionResponse, 0, len(groupActors[i]))
+		for _, actorID := range groupActors[i] {
+			items[i].Actors = append(items[i].Actors, MentionResponse{UserID: actorID, Username: names[actorID]})
+		}
+		items[i].Message = notificationMessage(items[i].Type, items[i].Actors, items[i].ActorCount)
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
+
+// POST /notifications/read ทำเครื่องหมายว่าอ่านแล้วตาม ids ใช้กับ ids ของกลุ่มได้
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var req MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.markRead(c, func(query *gorm.DB) *gorm.DB {
+		return query.Where("id IN ?", req.IDs)
+	})
+}
+
+// POST /notifications/read-all
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	h.markRead(c, func(query *gorm.DB) *gorm.DB {
+		return query
+	})
+}
+
+func (h *NotificationHandler) markRead(c *gin.Context, scope func(*gorm.DB) *gorm.DB) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	result := h.db.Model(&models.Notifications{}).
+		Scopes(scope).
+		Where("userID = ? AND read_at IS NULL", user.ID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+// GET /notifications/preferences ทุกชนิดพร้อมสถานะเปิดปิด
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	h.respondPreferences(c, user.ID)
+}
+
+// PUT /notifications/preferences รับ {"reaction": false} ชนิดที่ไม่ได้ส่งมาคงค่าเดิม
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	var req map[string]bool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferences := make([]models.NotificationPreferences, 0, len(req))
+	for notificationType, enabled := range req {
+		if _, ok := notificationVerbs[notificationType]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown notification type " + notificationType})
+			return
+		}
+		preferences = append(preferences, models.NotificationPreferences{UserID: user.ID, Type: notificationType, Enabled: enabled})
+	}
+
+	if len(preferences) > 0 {
+		err := h.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&preferences).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	h.respondPreferences(c, user.ID)
+}
+
+func (h *NotificationHandler) respondPreferences(c *gin.Context, userID uint) {
+	var stored []models.NotificationPreferences
+	if err := h.db.Where("userID = ?", userID).Find(&stored).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := map[string]bool{}
+	for _, notificationType := range models.NotificationTypes {
+		response[notificationType] = true
+	}
+	for _, preference := range stored {
+		response[preference.Type] = preference.Enabled
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupedNotifications(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์ แล้วให้ผู้ใช้อื่นสามคนกด like โพสต์
+	db, owner, post := setupTestData(t)
+	reactionHandler := handlers.NewReactionHandler(db)
+	notificationHandler := handlers.NewNotificationHandler(db)
+	postID := strconv.FormatUint(uint64(post.PostID), 10)
+
+	request := func(username, method, path, body string, params gin.Params, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = params
+		c.Request, _ = http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		handle(c)
+		return w
+	}
+	like := func(username string) {
+		params := gin.Params{{Key: "id", Value: postID}, {Key: "type", Value: "like"}}
+		w := request(username, "PUT", "/posts/"+postID+"/reactions/like", "", params, reactionHandler.AddReaction)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	for _, username := range []string{"alice", "bobby", "carol"} {
+		user := models.Users{Username: username, Fullname: username, Email: username + "@example.com"}
+		assert.NoError(t, db.Create(&user).Error)
+		like(username)
+	}
+	// เจ้าของกด like โพสต์ตัวเองไม่มีการแจ้งเตือน
+	like(owner.Username)
+
+	var list struct {
+		Items []handlers.NotificationGroupResponse `json:"items"`
+		Total int64                                `json:"total"`
+	}
+	w := request(owner.Username, "GET", "/notifications", "", nil, notificationHandler.ListNotifications)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, int64(1), list.Total)
+	assert.Equal(t, int64(3), list.Items[0].ActorCount)
+	assert.Equal(t, int64(3), list.Items[0].UnreadCount)
+	assert.Equal(t, "carol and 2 others reacted to your post", list.Items[0].Message)
+
+	// ปิดการแจ้งเตือน reaction แล้วไม่มีการแจ้งเตือนใหม่
+	w = request(owner.Username, "PUT", "/notifications/preferences", `{"reaction": false}`, nil, notificationHandler.UpdatePreferences)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var preferences map[string]bool
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &preferences))
+	assert.False(t, preferences["reaction"])
+	assert.True(t, preferences["comment"])
+
+	user := models.Users{Username: "daniel", Fullname: "daniel", Email: "daniel@example.com"}
+	assert.NoError(t, db.Create(&user).Error)
+	like(user.Username)
+
+	var count int64
+	db.Model(&models.Notifications{}).Where("userID = ?", owner.ID).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	// อ่านทั้งกลุ่มด้วย ids แล้วไม่เหลือที่ยังไม่อ่าน
+	body, _ := json.Marshal(handlers.MarkNotificationsReadRequest{IDs: list.Items[0].IDs})
+	w = request(owner.Username, "POST", "/notifications/read", string(body), nil, notificationHandler.MarkRead)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = request(owner.Username, "GET", "/notifications?unread=true", "", nil, notificationHandler.ListNotifications)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, int64(0), list.Total)
+}
@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/outbox"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRelay(t *testing.T) {
+	db, user, _ := setupTestData(t)
+	defer events.Subscribe(outbox.Record)()
+
+	request := func(path string, body interface{}, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", user.Username)
+		c.Request, _ = http.NewRequest("POST", path, bytes.NewReader(data))
+		handle(c)
+		return w
+	}
+
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+
+	// สมัครสมาชิกและสร้างโพสต์ published ได้ event ใน outbox ใน transaction เดียวกัน
+	w := request("/register", handlers.CreateUserRequest{
+		Username:       "bobby_b",
+		HashedPassword: "correct-horse-battery",
+		FullName:       "Bobby Brown",
+		Email:          "bobby@example.com",
+	}, userHandler.Register)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	postHandler := handlers.NewPostHandler(db)
+	for _, title := range []string{"first post", "second post"} {
+		w = request("/posts", handlers.CreatePostRequest{Title: title, Body: "body123", Status: "published"}, postHandler.CreatePost)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	var rows []models.OutboxEvents
+	db.Order("id").Find(&rows)
+	var types []string
+	for _, row := range rows {
+		types = append(types, row.EventType)
+	}
+	assert.Equal(t, []string{
+		events.UserRegistered,
+		events.PostCreated, events.PostPublished,
+		events.PostCreated, events.PostPublished,
+	}, types)
+
+	// โพสต์แรกส่งไม่สำเร็จ event ถัดไปของโพสต์แรกต้องรอ แต่โพสต์ที่สองส่งต่อได้
+	firstPost := rows[1].AggregateID
+	failing := true
+	var received []uint
+	bus := outbox.NewBus()
+	bus.Handle("*", func(ctx context.Context, msg outbox.Message) error {
+		if failing && msg.AggregateType == outbox.AggregatePost && msg.AggregateID == firstPost {
+			return errors.New("sink unavailable")
+		}
+		received = append(received, msg.ID)
+		return nil
+	})
+	relay := outbox.NewRelay(db, bus, time.Second, time.Hour)
+
+	n, err := relay.RelayPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []uint{rows[0].ID, rows[3].ID, rows[4].ID}, received)
+
+	var failed models.OutboxEvents
+	db.First(&failed, rows[1].ID)
+	assert.Nil(t, failed.PublishedAt)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "sink unavailable", failed.LastError)
+
+	// ส่งซ้ำได้ตามลำดับเดิม
+	failing = false
+	received = nil
+	n, err = relay.RelayPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []uint{rows[1].ID, rows[2].ID}, received)
+}
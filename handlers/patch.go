@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+	maxPatchSize          = 1 << 20
+)
+
+// ใช้ patch จาก request กับ current แล้วเขียนผลลงใน dst (struct ชนิดเดียวกับ current)
+// ตรวจ validation เฉพาะ field ที่ถูกเปลี่ยน คืนชื่อ field ใน JSON ที่เปลี่ยน
+// ถ้าไม่ผ่านจะตอบ error เองและคืน false
+func bindPatch(c *gin.Context, current, dst interface{}) ([]string, bool) {
+	original, err := json.Marshal(current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// application/json ถือเป็น merge patch เหมือนที่ client เดิมส่งมา
+	var patched []byte
+	switch c.ContentType() {
+	case mergePatchContentType, binding.MIMEJSON, "":
+		patched, err = utils.MergePatch(original, body)
+	case jsonPatchContentType:
+		patched, err = utils.ApplyJSONPatch(original, body)
+	default:
+		c.Header("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported patch format"})
+		return nil, false
+	}
+	if errors.Is(err, utils.ErrPatchTestFailed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// field ที่ไม่รู้จักหรือชนิดข้อมูลผิดถือว่า patch ไม่ถูกต้อง
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	changed, err := changedFields(original, patched)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if len(changed) == 0 {
+		return changed, true
+	}
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := v.StructPartial(dst, structFieldNames(dst, changed)...); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+	}
+
+	return changed, true
+}
+
+// หาชื่อ field ระดับบนสุดที่ค่าเปลี่ยนไปหลัง patch
+func changedFields(original, patched []byte) ([]string, error) {
+	var before, after map[string]json.RawMessage
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for key, value := range before {
+		next, ok := after[key]
+		if !ok {
+			next = json.RawMessage("null")
+		}
+		if !utils.JSONEqual(value, next) {
+			changed = append(changed, key)
+		}
+	}
+	return changed, nil
+}
+
+func hasField(fields []string, name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// แปลงชื่อ field ใน JSON เป็นชื่อ field ของ struct สำหรับ StructPartial
+func structFieldNames(obj interface{}, jsonNames []string) []string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if hasField(jsonNames, name) {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
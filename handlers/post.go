@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/events"
 	"github.com/NopparootSuree/go-social/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -20,30 +24,246 @@ func NewPostHandler(db *gorm.DB) *PostHandler {
 }
 
 type CreatePostResponse struct {
-	PostID    uint      `json:"postID" binding:"required"`
-	Title     string    `json:"title" binding:"required"`
-	Body      string    `json:"body" binding:"required"`
-	UserID    uint      `json:"userID" binding:"required"`
-	Status    string    `json:"status" binding:"required"`
-	CreatedAt time.Time `json:"createdAt"`
+	PostID       uint                 `json:"postID" binding:"required"`
+	Title        string               `json:"title" binding:"required"`
+	Body         string               `json:"body" binding:"required"`
+	UserID       uint                 `json:"userID" binding:"required"`
+	Status       string               `json:"status" binding:"required"`
+	Visibility   string               `json:"visibility"`
+	PublishAt    *time.Time           `json:"publishAt"`
+	Tags         []string             `json:"tags"`
+	Mentions     []MentionResponse    `json:"mentions"`
+	Attachments  []AttachmentResponse `json:"attachments"`
+	CommentCount int64                `json:"commentCount"`
+	Reactions    map[string]int64     `json:"reactions"`
+	ReactedByMe  []string             `json:"reactedByMe"`
+	CreatedAt    time.Time            `json:"createdAt"`
 }
 
 type CreatePostRequest struct {
-	Title  string `json:"title" binding:"required,min=6"`
-	Body   string `json:"body" binding:"required,min=6"`
-	UserID uint   `json:"userID" binding:"required,min=1"`
-	Status string `json:"status" binding:"required"`
+	Title      string     `json:"title" binding:"required,min=6"`
+	Body       string     `json:"body" binding:"required,min=6"`
+	Status     string     `json:"status" binding:"required,oneof=draft scheduled published"`
+	Visibility string     `json:"visibility" binding:"omitempty,oneof=public followers mentioned private"`
+	PublishAt  *time.Time `json:"publishAt"`
 }
 
 type CreatePostUpdateRequest struct {
-	Title  string `json:"title" binding:"required,min=6"`
-	Body   string `json:"body" binding:"required,min=6"`
-	Status string `json:"status" binding:"required"`
+	Title      string     `json:"title" binding:"required,min=6"`
+	Body       string     `json:"body" binding:"required,min=6"`
+	Status     string     `json:"status" binding:"required,oneof=draft scheduled published archived"`
+	Visibility string     `json:"visibility" binding:"omitempty,oneof=public followers mentioned private"`
+	PublishAt  *time.Time `json:"publishAt"`
+}
+
+// field ของโพสต์ที่แก้ไขผ่าน PATCH ได้
+type PostPatch struct {
+	Title      string     `json:"title" binding:"required,min=6"`
+	Body       string     `json:"body" binding:"required,min=6"`
+	Status     string     `json:"status" binding:"required,oneof=draft scheduled published archived"`
+	Visibility string     `json:"visibility" binding:"required,oneof=public followers mentioned private"`
+	PublishAt  *time.Time `json:"publishAt"`
+}
+
+func newPostResponse(post models.Posts) CreatePostResponse {
+	return CreatePostResponse{
+		PostID:     post.PostID,
+		Title:      post.Title,
+		Body:       post.Body,
+		UserID:     post.UserID,
+		Status:     post.Status,
+		Visibility: post.Visibility,
+		PublishAt:  post.PublishAt,
+		CreatedAt:  post.CreatedAt,
+	}
+}
+
+// สร้าง response ของโพสต์หลายรายการพร้อมข้อมูลประกอบ โดย query ข้อมูลประกอบครั้งเดียวต่อทั้งชุด
+func postResponses(db *gorm.DB, posts []models.Posts, viewerID uint) ([]CreatePostResponse, error) {
+	response := []CreatePostResponse{}
+	if len(posts) == 0 {
+		return response, nil
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.PostID)
+	}
+
+	// จำนวนความคิดเห็นที่ยังไม่ถูกลบของแต่ละโพสต์
+	var counts []struct {
+		PostID uint `gorm:"column:postID"`
+		Total  int64
+	}
+	err := db.Model(&models.Comments{}).
+		Select("postID, COUNT(*) AS total").
+		Where("postID IN ? AND deleted_at IS NULL", ids).
+		Group("postID").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	commentCounts := map[uint]int64{}
+	for _, count := range counts {
+		commentCounts[count.PostID] = count.Total
+	}
+
+	reactions, err := reactionSummaries(db, ids, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// hashtag และ mention ของแต่ละโพสต์
+	var tagRows []struct {
+		PostID uint `gorm:"column:postID"`
+		Name   string
+	}
+	err = db.Table("post_tags").
+		Select("post_tags.postID, tags.name").
+		Joins("JOIN tags ON tags.id = post_tags.tagID").
+		Where("post_tags.postID IN ?", ids).
+		Order("tags.name").
+		Scan(&tagRows).Error
+	if err != nil {
+		return nil, err
+	}
+	tags := map[uint][]string{}
+	for _, row := range tagRows {
+		tags[row.PostID] = append(tags[row.PostID], row.Name)
+	}
+
+	var mentionRows []struct {
+		PostID   uint `gorm:"column:postID"`
+		UserID   uint `gorm:"column:userID"`
+		Username string
+	}
+	err = db.Table("mentions").
+		Select("mentions.postID, mentions.userID, users.username").
+		Joins("JOIN users ON users.id = mentions.userID AND users.deleted_at IS NULL").
+		Where("mentions.postID IN ?", ids).
+		Order("users.username").
+		Scan(&mentionRows).Error
+	if err != nil {
+		return nil, err
+	}
+	mentions := map[uint][]MentionResponse{}
+	for _, row := range mentionRows {
+		mentions[row.PostID] = append(mentions[row.PostID], MentionResponse{UserID: row.UserID, Username: row.Username})
+	}
+
+	var attachmentRows []models.Attachments
+	if err := db.Where("postID IN ?", ids).Order("id").Find(&attachmentRows).Error; err != nil {
+		return nil, err
+	}
+	attachments := map[uint][]AttachmentResponse{}
+	for _, attachment := range attachmentRows {
+		attachments[attachment.PostID] = append(attachments[attachment.PostID], newAttachmentResponse(attachment))
+	}
+
+	for _, post := range posts {
+		item := newPostResponse(post)
+		item.Tags = tags[post.PostID]
+		item.Mentions = mentions[post.PostID]
+		if item.Tags == nil {
+			item.Tags = []string{}
+		}
+		if item.Mentions == nil {
+			item.Mentions = []MentionResponse{}
+		}
+		item.Attachments = attachments[post.PostID]
+		if item.Attachments == nil {
+			item.Attachments = []AttachmentResponse{}
+		}
+		item.CommentCount = commentCounts[post.PostID]
+		item.Reactions = reactions[post.PostID].Counts
+		item.ReactedByMe = reactions[post.PostID].ReactedByMe
+		response = append(response, item)
+	}
+	return response, nil
+}
+
+func postResponse(db *gorm.DB, post models.Posts, viewerID uint) (CreatePostResponse, error) {
+	response, err := postResponses(db, []models.Posts{post}, viewerID)
+	if err != nil {
+		return CreatePostResponse{}, err
+	}
+	return response[0], nil
+}
+
+// หาโพสต์ที่ผู้ใช้คนนี้มองเห็น ถ้าไม่เจอจะตอบ 404 และคืน false
+func findVisiblePost(c *gin.Context, db *gorm.DB, viewerID uint) (models.Posts, bool) {
+	var post models.Posts
+	result := db.Scopes(models.VisiblePosts(viewerID)).First(&post, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		return post, false
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return post, false
+	}
+	return post, true
+}
+
+// หาโพสต์ที่ผู้ใช้คนนี้แก้ไขได้ คือเจ้าของโพสต์หรือ admin ถ้าไม่ได้จะตอบ error และคืน false
+// โพสต์ที่มองไม่เห็นตอบ 404 เหมือนไม่มีอยู่
+func (h *PostHandler) findEditablePost(c *gin.Context) (models.Users, models.Posts, bool) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return user, models.Posts{}, false
+	}
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return user, post, false
+	}
+	if post.UserID != user.ID && !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can edit this post"})
+		return user, post, false
+	}
+	return user, post, true
+}
+
+// ส่ง event เมื่อโพสต์เพิ่งเปลี่ยนเป็น published ต้องเรียกภายใน transaction
+func publishPostEvent(tx *gorm.DB, previousStatus string, post models.Posts) error {
+	if post.Status != models.PostStatusPublished || previousStatus == models.PostStatusPublished {
+		return nil
+	}
+	postID := post.PostID
+	return events.Publish(tx, events.Event{Type: events.PostPublished, ActorID: post.UserID, PostID: &postID})
+}
+
+// คำนวณเวลา publish ตามสถานะที่ต้องการ
+func resolvePublishAt(status string, current, requested *time.Time) (*time.Time, error) {
+	now := time.Now()
+	switch status {
+	case models.PostStatusScheduled:
+		publishAt := requested
+		if publishAt == nil {
+			publishAt = current
+		}
+		if publishAt == nil || !publishAt.After(now) {
+			return nil, errors.New("publishAt must be in the future for scheduled posts")
+		}
+		return publishAt, nil
+	case models.PostStatusPublished:
+		if current != nil && !current.After(now) {
+			return current, nil
+		}
+		return &now, nil
+	case models.PostStatusDraft:
+		return nil, nil
+	default:
+		return current, nil
+	}
 }
 
 func (h *PostHandler) ListPosts(c *gin.Context) {
+	viewerID := currentUserID(c, h.db)
+
 	var posts []models.Posts
-	result := h.db.Find(&posts)
+	result := h.db.Scopes(models.VisiblePosts(viewerID)).Find(&posts)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
@@ -54,17 +274,10 @@ func (h *PostHandler) ListPosts(c *gin.Context) {
 		return
 	}
 
-	var response []CreatePostResponse
-
-	for _, post := range posts {
-		response = append(response, CreatePostResponse{
-			PostID:    post.PostID,
-			Title:     post.Title,
-			Body:      post.Body,
-			UserID:    post.UserID,
-			Status:    post.Status,
-			CreatedAt: post.CreatedAt,
-		})
+	response, err := postResponses(h.db, posts, viewerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -77,28 +290,59 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
+	// ผู้เขียนคือผู้ที่ login อยู่เสมอ
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	publishAt, err := resolvePublishAt(req.Status, nil, req.PublishAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+
 	post := models.Posts{
-		Title:  req.Title,
-		Body:   req.Body,
-		UserID: req.UserID,
-		Status: req.Status,
+		Title:      req.Title,
+		Body:       req.Body,
+		UserID:     user.ID,
+		Status:     req.Status,
+		Visibility: visibility,
+		PublishAt:  publishAt,
+		Version:    1,
 	}
 
-	result := h.db.Create(&post)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if err := syncPostEntities(tx, post); err != nil {
+			return err
+		}
+		postID := post.PostID
+		if err := events.Publish(tx, events.Event{Type: events.PostCreated, ActorID: post.UserID, PostID: &postID}); err != nil {
+			return err
+		}
+		return publishPostEvent(tx, "", post)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	response := CreatePostResponse{
-		PostID:    post.PostID,
-		Title:     post.Title,
-		Body:      post.Body,
-		UserID:    post.UserID,
-		Status:    post.Status,
-		CreatedAt: post.CreatedAt,
+	response, err := postResponse(h.db, post, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
+	c.Header("ETag", postETag(post.PostID, post.Version))
 	c.JSON(http.StatusCreated, response)
 
 }
@@ -106,20 +350,27 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 func (h *PostHandler) GetPost(c *gin.Context) {
 	id := c.Param("id")
 
+	viewerID := currentUserID(c, h.db)
+
 	var post models.Posts
-	result := h.db.First(&post, id)
+	result := h.db.Scopes(models.VisiblePosts(viewerID)).First(&post, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		return
+	}
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	response := CreatePostResponse{
-		PostID:    post.PostID,
-		Title:     post.Title,
-		Body:      post.Body,
-		UserID:    post.UserID,
-		Status:    post.Status,
-		CreatedAt: post.CreatedAt,
+	if notModified(c, postETag(post.PostID, post.Version)) {
+		return
+	}
+
+	response, err := postResponse(h.db, post, viewerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -127,61 +378,221 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 }
 
 func (h *PostHandler) UpdatePost(c *gin.Context) {
-	id := c.Param("id")
-
 	var req CreatePostUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var post models.Posts
-	result := h.db.First(&post, id)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+	user, post, ok := h.findEditablePost(c)
+	if !ok {
+		return
+	}
+
+	if !checkIfMatch(c, postETag(post.PostID, post.Version)) {
+		return
+	}
+
+	if !models.CanTransitionPost(post.Status, req.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("cannot change status from %s to %s", post.Status, req.Status)})
+		return
+	}
+
+	publishAt, err := resolvePublishAt(req.Status, post.PublishAt, req.PublishAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	updatesPost := map[string]interface{}{
-		"title":  req.Title,
-		"body":   req.Body,
-		"status": req.Status,
+		"title":      req.Title,
+		"body":       req.Body,
+		"status":     req.Status,
+		"publish_at": publishAt,
+	}
+	if req.Visibility != "" {
+		updatesPost["visibility"] = req.Visibility
 	}
 
-	result = h.db.Model(&post).Updates(updatesPost)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	// เก็บค่าเดิมไว้เป็น revision ก่อนแก้ไข
+	previousStatus := post.Status
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveRevision(tx, post, user.ID); err != nil {
+			return err
+		}
+		if err := auditPostUpdate(tx, c, post, updatesPost); err != nil {
+			return err
+		}
+		if err := updateVersioned(tx, &post, post.Version, updatesPost); err != nil {
+			return err
+		}
+		if err := syncPostEntities(tx, post); err != nil {
+			return err
+		}
+		return publishPostEvent(tx, previousStatus, post)
+	})
+	if err != nil {
+		respondSaveError(c, err)
+		return
+	}
+
+	// ตอบด้วยค่าที่บันทึกอยู่จริงในฐานข้อมูล
+	h.respondStoredPost(c, post.PostID)
+}
+
+// PATCH /posts/:id รับได้ทั้ง JSON Merge Patch และ JSON Patch
+func (h *PostHandler) PatchPost(c *gin.Context) {
+	user, post, ok := h.findEditablePost(c)
+	if !ok {
 		return
 	}
 
-	response := CreatePostResponse{
-		PostID:    post.PostID,
-		Title:     post.Title,
-		Body:      post.Body,
-		UserID:    post.UserID,
-		Status:    post.Status,
-		CreatedAt: post.CreatedAt,
+	if !checkIfMatch(c, postETag(post.PostID, post.Version)) {
+		return
+	}
+
+	current := PostPatch{
+		Title:      post.Title,
+		Body:       post.Body,
+		Status:     post.Status,
+		Visibility: post.Visibility,
+		PublishAt:  post.PublishAt,
+	}
+
+	var patched PostPatch
+	changed, ok := bindPatch(c, current, &patched)
+	if !ok {
+		return
+	}
+
+	updatesPost := map[string]interface{}{}
+	if hasField(changed, "title") {
+		updatesPost["title"] = patched.Title
+	}
+	if hasField(changed, "body") {
+		updatesPost["body"] = patched.Body
+	}
+	if hasField(changed, "status") {
+		if !models.CanTransitionPost(post.Status, patched.Status) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("cannot change status from %s to %s", post.Status, patched.Status)})
+			return
+		}
+		updatesPost["status"] = patched.Status
+	}
+	if hasField(changed, "visibility") {
+		updatesPost["visibility"] = patched.Visibility
+	}
+	if hasField(changed, "status") || hasField(changed, "publishAt") {
+		var requested *time.Time
+		if hasField(changed, "publishAt") {
+			requested = patched.PublishAt
+		}
+		publishAt, err := resolvePublishAt(patched.Status, post.PublishAt, requested)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updatesPost["publish_at"] = publishAt
+	}
+
+	if len(updatesPost) > 0 {
+		// เก็บค่าเดิมไว้เป็น revision ก่อนแก้ไข
+		previousStatus := post.Status
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := saveRevision(tx, post, user.ID); err != nil {
+				return err
+			}
+			if err := auditPostUpdate(tx, c, post, updatesPost); err != nil {
+				return err
+			}
+			if err := updateVersioned(tx, &post, post.Version, updatesPost); err != nil {
+				return err
+			}
+			if err := syncPostEntities(tx, post); err != nil {
+				return err
+			}
+			return publishPostEvent(tx, previousStatus, post)
+		})
+		if err != nil {
+			respondSaveError(c, err)
+			return
+		}
+	}
+
+	h.respondStoredPost(c, post.PostID)
+}
+
+// อ่านโพสต์จากฐานข้อมูลอีกครั้งแล้วตอบกลับ
+func (h *PostHandler) respondStoredPost(c *gin.Context, postID uint) {
+	var post models.Posts
+	if err := h.db.First(&post, postID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("ETag", postETag(post.PostID, post.Version))
+
+	response, err := postResponse(h.db, post, currentUserID(c, h.db))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	// if result.RowsAffected == 0 {
-	// 	c.JSON(http.StatusBadRequest, gin.H{"error": "cannot update record"})
-	// } else {
 	c.JSON(http.StatusOK, response)
-	// }
 }
 
 func (h *PostHandler) DeletePost(c *gin.Context) {
 	id := c.Param("id")
 
 	var post models.Posts
-	result := h.db.Delete(&post, id)
+	result := h.db.First(&post, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "record is not found"})
+		return
+	}
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+	if post.UserID != user.ID && !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can delete this post"})
+		return
+	}
+
+	if !checkIfMatch(c, postETag(post.PostID, post.Version)) {
+		return
+	}
+
+	// ย้ายลงถังขยะ จะถูกลบถาวรเมื่อพ้นระยะเวลาเก็บ
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result = tx.Where("version = ?", post.Version).Delete(&post)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		err := audit.Record(tx, auditActor(c, user), audit.Entry{
+			Action:     audit.ActionPostDelete,
+			TargetType: audit.TargetPost,
+			TargetID:   post.PostID,
+			Before:     map[string]interface{}{"title": post.Title, "userID": post.UserID},
+		})
+		if err != nil {
+			return err
+		}
+		postID := post.PostID
+		return events.Publish(tx, events.Event{Type: events.PostDeleted, ActorID: user.ID, UserID: post.UserID, PostID: &postID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	if result.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "record is not found"})
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": errVersionConflict.Error()})
 	} else {
 		c.JSON(http.StatusNoContent, gin.H{"Success": "removed record"})
 	}
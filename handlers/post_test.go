@@ -2,26 +2,29 @@ package handlers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/fixtures"
 	"github.com/NopparootSuree/go-social/handlers"
 	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/scheduler"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/benbjohnson/clock"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-func teardownTestDBs(db *gorm.DB) {
-	db.Migrator().DropTable(&models.Posts{})
-	db.Exec("DELETE FROM products")
-}
-
 func TestListPosts(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -31,22 +34,25 @@ func TestListPosts(t *testing.T) {
 	assert.NoError(t, err)
 	teardownTestDBs(db)
 	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Posts{})
+	err = db.AutoMigrate(testTables...)
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
 	postHandler := handlers.NewPostHandler(db)
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	err = db.Create(&author).Error
+	assert.NoError(t, err)
 
 	// สร้างเครื่องมือทดสอบ HTTP และเรียกใช้งานฟังก์ชัน CreateUser
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Set("username", author.Username)
 
 	// สร้างข้อมูล JSON สำหรับการสร้างผู้ใช้ใหม่
 	createPostReq := handlers.CreatePostRequest{
 		Title:  "title_test",
 		Body:   "unitTest",
-		UserID: 1,
-		Status: "unit@test.com",
+		Status: "published",
 	}
 
 	createPostJSON, _ := json.Marshal(createPostReq)
@@ -66,7 +72,7 @@ func TestListPosts(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, createPostReq.Title, post.Title)
 	assert.Equal(t, createPostReq.Body, post.Body)
-	assert.Equal(t, createPostReq.UserID, post.UserID)
+	assert.Equal(t, author.ID, post.UserID)
 	assert.Equal(t, createPostReq.Status, post.Status)
 
 }
@@ -78,7 +84,7 @@ func TestGetPost(t *testing.T) {
 	assert.NoError(t, err)
 	teardownTestDBs(db)
 	// Run migrations สำหรับสร้างตาราง Posts
-	err = db.AutoMigrate(&models.Posts{})
+	err = db.AutoMigrate(testTables...)
 	assert.NoError(t, err)
 
 	// สร้าง PostHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
@@ -120,31 +126,107 @@ func TestGetPost(t *testing.T) {
 
 }
 
+func TestListPostsWithFixtures(t *testing.T) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	// ข้อมูลจาก fixtures มีทั้ง draft บัญชี private คำขอติดตามที่รออนุมัติ และ visibility ทุกแบบ
+	cfg := fixtures.DefaultConfig()
+	cfg.Users = 15
+	cfg.FollowsPerUser = 4
+	cfg.PostsPerUser = 4
+	data := loadFixtures(t, db, cfg)
+
+	authors := map[uint]models.Users{}
+	for _, user := range data.Users {
+		authors[user.ID] = user
+	}
+	type pair struct{ follower, following uint }
+	accepted := map[pair]bool{}
+	for _, follow := range data.Follows {
+		if follow.Status == models.FollowStatusAccepted {
+			accepted[pair{follow.FollowerUserID, follow.FollowingUserID}] = true
+		}
+	}
+	// กติกาเดียวกับ models.VisiblePosts เมื่อไม่มีการบล็อกและ mention
+	visible := func(post models.Posts, viewerID uint) bool {
+		if post.UserID == viewerID {
+			return true
+		}
+		if post.Status != models.PostStatusPublished {
+			return false
+		}
+		if post.Visibility == models.VisibilityPublic && !authors[post.UserID].Private {
+			return true
+		}
+		following := viewerID != 0 && accepted[pair{viewerID, post.UserID}]
+		return following && (post.Visibility == models.VisibilityPublic || post.Visibility == models.VisibilityFollowers)
+	}
+
+	postHandler := handlers.NewPostHandler(db)
+	viewers := append([]models.Users{{}}, data.Users...)
+	for _, viewer := range viewers {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		if viewer.ID != 0 {
+			c.Set("username", viewer.Username)
+		}
+		c.Request, _ = http.NewRequest("GET", "/posts", nil)
+		postHandler.ListPosts(c)
+
+		got := []uint{}
+		if w.Code != http.StatusNotFound {
+			assert.Equal(t, http.StatusOK, w.Code)
+			var response []handlers.CreatePostResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for _, post := range response {
+				got = append(got, post.PostID)
+			}
+		}
+
+		want := []uint{}
+		for _, post := range data.Posts {
+			if visible(post, viewer.ID) {
+				want = append(want, post.PostID)
+			}
+		}
+		assert.ElementsMatch(t, want, got, "viewer %q", viewer.Username)
+	}
+}
+
 func TestCreatePost(t *testing.T) {
 	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	assert.NoError(t, err)
 	teardownTestDBs(db)
 	// Run migrations สำหรับสร้างตาราง Posts
-	err = db.AutoMigrate(&models.Posts{})
+	err = db.AutoMigrate(testTables...)
 	assert.NoError(t, err)
 
 	// สร้าง PostHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
 	postHandler := handlers.NewPostHandler(db)
-
-	// เพิ่มข้อมูลโพสต์ในฐานข้อมูลเพื่อใช้ในการทดสอบ
-	createPostJson := handlers.CreatePostRequest{
-		Title:  "Test Post",
-		Body:   "This is a test post",
-		UserID: 1,
-		Status: "published",
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	for _, user := range []*models.Users{&author, &other} {
+		assert.NoError(t, db.Create(user).Error)
 	}
 
-	createPostJSON, _ := json.Marshal(createPostJson)
+	// เพิ่มข้อมูลโพสต์ในฐานข้อมูลเพื่อใช้ในการทดสอบ userID ที่ส่งมาต้องถูกเพิกเฉย
+	createPostJSON, _ := json.Marshal(map[string]interface{}{
+		"title":  "Test Post",
+		"body":   "This is a test post",
+		"userID": other.ID,
+		"status": "published",
+	})
 
 	// สร้างเครื่องมือทดสอบ HTTP และเรียกใช้งานฟังก์ชัน CreateUser
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Set("username", author.Username)
 	c.Request, _ = http.NewRequest("POST", "/posts", bytes.NewReader(createPostJSON))
 	postHandler.CreatePost(c)
 
@@ -161,8 +243,16 @@ func TestCreatePost(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, createPostReq.Title, post.Title)
 	assert.Equal(t, createPostReq.Body, post.Body)
-	assert.Equal(t, createPostReq.UserID, post.UserID)
+	assert.Equal(t, author.ID, post.UserID)
+	assert.Equal(t, author.ID, createPostReq.UserID)
 	assert.Equal(t, createPostReq.Status, post.Status)
+
+	// ไม่ได้ login สร้างโพสต์ไม่ได้
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/posts", bytes.NewReader(createPostJSON))
+	postHandler.CreatePost(c)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
 func TestUpdatePost(t *testing.T) {
@@ -171,19 +261,22 @@ func TestUpdatePost(t *testing.T) {
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	assert.NoError(t, err)
 	teardownTestDBs(db)
-	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Posts{})
+	// Run migrations สำหรับสร้างตาราง Posts
+	err = db.AutoMigrate(testTables...)
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
 	postHandler := handlers.NewPostHandler(db)
 	assert.NoError(t, err)
 	// เพิ่มข้อมูลผู้ใช้ในฐานข้อมูลเพื่อใช้ในการทดสอบ
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	err = db.Create(&author).Error
+	assert.NoError(t, err)
 	post := models.Posts{
 		Title:  "title123",
 		Body:   "body123",
-		UserID: 1,
-		Status: "status456",
+		UserID: author.ID,
+		Status: "draft",
 	}
 	err = db.Create(&post).Error
 	assert.NoError(t, err)
@@ -191,7 +284,9 @@ func TestUpdatePost(t *testing.T) {
 	// เตรียม HTTP request สำหรับการเรียกใช้งาน UpdateUser
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Request, _ = http.NewRequest("PUT", "/posts/1", bytes.NewReader([]byte(`{"title": "it title","body": "it body","status": "it status"}`)))
+	c.Set("username", author.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+	c.Request, _ = http.NewRequest("PUT", "/posts/1", bytes.NewReader([]byte(`{"title": "it title","body": "it body","status": "published"}`)))
 	// // เรียกใช้งาน UpdateUser ผ่าน UserHandler
 	postHandler.UpdatePost(c)
 	// ตรวจสอบว่าการอัปเดตข้อมูลผู้ใช้สำเร็จโดยตรวจสอบสถานะ HTTP response code และแปลง JSON response เป็น CreateUserResponse
@@ -205,7 +300,17 @@ func TestUpdatePost(t *testing.T) {
 	assert.Equal(t, uint(1), response.PostID)
 	assert.Equal(t, "it title", response.Title)
 	assert.Equal(t, "it body", response.Body)
-	assert.Equal(t, "it status", response.Status)
+	assert.Equal(t, "published", response.Status)
+	assert.NotNil(t, response.PublishAt)
+
+	// ค่าก่อนแก้ไขต้องถูกเก็บเป็น revision แรก
+	var revision models.PostRevisions
+	err = db.Where("postID = ?", post.PostID).First(&revision).Error
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), revision.Revision)
+	assert.Equal(t, "title123", revision.Title)
+	assert.Equal(t, "body123", revision.Body)
+	assert.Equal(t, "draft", revision.Status)
 }
 
 func TestDeletePost(t *testing.T) {
@@ -214,16 +319,21 @@ func TestDeletePost(t *testing.T) {
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	assert.NoError(t, err)
 	teardownTestDBs(db)
+	teardownTestDB(db)
 	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Posts{})
+	err = db.AutoMigrate(append(testTables, &models.Users{})...)
+	assert.NoError(t, err)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	err = db.Create(&author).Error
 	assert.NoError(t, err)
 
 	// เตรียมข้อมูลผู้ใช้ในฐานข้อมูลทดสอบ
 	post := models.Posts{
 		Title:  "title123",
 		Body:   "body123",
-		UserID: 1,
-		Status: "status456",
+		UserID: author.ID,
+		Status: "draft",
 	}
 
 	err = db.Create(&post).Error
@@ -235,6 +345,7 @@ func TestDeletePost(t *testing.T) {
 	// สร้างเครื่องมือทดสอบ HTTP และเรียกใช้งานฟังก์ชัน DeleteUser
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Set("username", author.Username)
 
 	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
 	postHandler.DeletePost(c)
@@ -246,4 +357,644 @@ func TestDeletePost(t *testing.T) {
 	var deletedPost models.Posts
 	err = db.First(&deletedPost, post.PostID).Error
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	// โพสต์ยังอยู่ในถังขยะ
+	err = db.Unscoped().First(&deletedPost, post.PostID).Error
+	assert.NoError(t, err)
+	assert.True(t, deletedPost.DeletedAt.Valid)
+}
+
+func TestRestorePost(t *testing.T) {
+	// เตรียมฐานข้อมูล MySQL ในหน่วยทดสอบ
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	teardownTestDB(db)
+	err = db.AutoMigrate(append(testTables, &models.Users{})...)
+	assert.NoError(t, err)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	err = db.Create(&[]*models.Users{&author, &other}).Error
+	assert.NoError(t, err)
+
+	post := models.Posts{Title: "title123", Body: "body123", UserID: author.ID, Status: "published"}
+	err = db.Create(&post).Error
+	assert.NoError(t, err)
+	err = db.Delete(&post).Error
+	assert.NoError(t, err)
+
+	postHandler := handlers.NewPostHandler(db)
+
+	request := func(username string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+		c.Request, _ = http.NewRequest("GET", "/posts/trash", nil)
+		handle(c)
+		return w
+	}
+
+	// เจ้าของเห็นโพสต์ในถังขยะ
+	w := request(author.Username, postHandler.ListTrash)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var trash struct {
+		Items []handlers.TrashedPostResponse `json:"items"`
+		Total int64                          `json:"total"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &trash)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), trash.Total)
+	assert.Equal(t, post.PostID, trash.Items[0].PostID)
+
+	// คนอื่นไม่เห็นและกู้คืนไม่ได้
+	w = request(other.Username, postHandler.ListTrash)
+	err = json.Unmarshal(w.Body.Bytes(), &trash)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), trash.Total)
+
+	w = request(other.Username, postHandler.RestorePost)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = request(author.Username, postHandler.RestorePost)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var restored models.Posts
+	err = db.First(&restored, post.PostID).Error
+	assert.NoError(t, err)
+}
+
+func TestTrashPurge(t *testing.T) {
+	db, author, _ := setupTestData(t)
+	db.Migrator().DropTable(privacyTables...)
+	err := db.AutoMigrate(privacyTables...)
+	assert.NoError(t, err)
+
+	store, err := storage.NewLocal(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	putBlob := func(key string) {
+		assert.NoError(t, store.Put(context.Background(), key, bytes.NewReader([]byte("data")), 4, "image/jpeg"))
+	}
+	blobExists := func(key string) bool {
+		blob, err := store.Get(context.Background(), key)
+		if err != nil {
+			return false
+		}
+		blob.Close()
+		return true
+	}
+
+	// โพสต์ที่มีไฟล์แนบ ความคิดเห็น และ reaction
+	createPost := func(title string) models.Posts {
+		post := models.Posts{Title: title, Body: "body123", UserID: author.ID, Status: "published"}
+		assert.NoError(t, db.Create(&post).Error)
+		key := fmt.Sprintf("posts/%d/photo.jpg", post.PostID)
+		thumbnail := fmt.Sprintf("posts/%d/thumb.jpg", post.PostID)
+		putBlob(key)
+		putBlob(thumbnail)
+		assert.NoError(t, db.Create(&models.Attachments{PostID: post.PostID, UserID: author.ID, Key: key, ThumbnailKey: thumbnail}).Error)
+		assert.NoError(t, db.Create(&models.Comments{PostID: post.PostID, UserID: author.ID, Body: "comment"}).Error)
+		assert.NoError(t, db.Create(&models.Reactions{PostID: post.PostID, UserID: author.ID, Type: "like"}).Error)
+		return post
+	}
+	createUser := func(username string) models.Users {
+		user := models.Users{Username: username, Fullname: username, Email: username + "@example.com", AvatarKey: "avatars/" + username + ".jpg"}
+		assert.NoError(t, db.Create(&user).Error)
+		putBlob(user.AvatarKey)
+		return user
+	}
+
+	expiredPost := createPost("expired post")
+	recentPost := createPost("recent post")
+	expiredUser := createUser("expired_user")
+	recentUser := createUser("recent_user")
+
+	// ย้ายลงถังขยะ รายการที่ลบทีหลังยังไม่หมดระยะเก็บ
+	retention := 30 * 24 * time.Hour
+	trashedAt := time.Now()
+	later := trashedAt.Add(10 * 24 * time.Hour)
+	assert.NoError(t, db.Model(&models.Posts{}).Where("postID = ?", expiredPost.PostID).Update("deleted_at", trashedAt).Error)
+	assert.NoError(t, db.Model(&models.Posts{}).Where("postID = ?", recentPost.PostID).Update("deleted_at", later).Error)
+	assert.NoError(t, db.Model(&models.Users{}).Where("id = ?", expiredUser.ID).Update("deleted_at", trashedAt).Error)
+	assert.NoError(t, db.Model(&models.Users{}).Where("id = ?", recentUser.ID).Update("deleted_at", later).Error)
+
+	mock := clock.NewMock()
+	mock.Set(trashedAt.Add(retention - time.Hour))
+	purger := scheduler.NewTrashPurger(db, store, retention).WithClock(mock)
+
+	// ยังไม่ถึงระยะเก็บไม่มีอะไรถูกลบ
+	users, posts, err := purger.PurgeExpired()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), users)
+	assert.Equal(t, int64(0), posts)
+
+	mock.Add(2 * time.Hour)
+	users, posts, err = purger.PurgeExpired()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), users)
+	assert.Equal(t, int64(1), posts)
+
+	count := func(model interface{}, query string, args ...interface{}) int64 {
+		var n int64
+		assert.NoError(t, db.Unscoped().Model(model).Where(query, args...).Count(&n).Error)
+		return n
+	}
+
+	// รายการที่หมดระยะเก็บถูกลบทั้งแถวและไฟล์
+	assert.Equal(t, int64(0), count(&models.Posts{}, "postID = ?", expiredPost.PostID))
+	assert.Equal(t, int64(0), count(&models.Users{}, "id = ?", expiredUser.ID))
+	for _, model := range []interface{}{&models.Attachments{}, &models.Comments{}, &models.Reactions{}} {
+		assert.Equal(t, int64(0), count(model, "postID = ?", expiredPost.PostID))
+	}
+	assert.False(t, blobExists(fmt.Sprintf("posts/%d/photo.jpg", expiredPost.PostID)))
+	assert.False(t, blobExists(fmt.Sprintf("posts/%d/thumb.jpg", expiredPost.PostID)))
+	assert.False(t, blobExists(expiredUser.AvatarKey))
+
+	// รายการที่ยังไม่หมดระยะเก็บยังอยู่ครบ
+	assert.Equal(t, int64(1), count(&models.Posts{}, "postID = ?", recentPost.PostID))
+	assert.Equal(t, int64(1), count(&models.Users{}, "id = ?", recentUser.ID))
+	for _, model := range []interface{}{&models.Attachments{}, &models.Comments{}, &models.Reactions{}} {
+		assert.Equal(t, int64(1), count(model, "postID = ?", recentPost.PostID))
+	}
+	assert.True(t, blobExists(fmt.Sprintf("posts/%d/photo.jpg", recentPost.PostID)))
+	assert.True(t, blobExists(fmt.Sprintf("posts/%d/thumb.jpg", recentPost.PostID)))
+	assert.True(t, blobExists(recentUser.AvatarKey))
+}
+
+func TestUpdatePostInvalidTransition(t *testing.T) {
+	// เตรียมฐานข้อมูล MySQL ในการเชื่อมต่อกับฐานข้อมูลที่ใช้ในการทดสอบ
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	// Run migrations สำหรับสร้างตาราง Posts
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	postHandler := handlers.NewPostHandler(db)
+
+	// โพสต์ที่ published แล้วกลับไปเป็น draft ไม่ได้
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	err = db.Create(&author).Error
+	assert.NoError(t, err)
+	post := models.Posts{
+		Title:  "title123",
+		Body:   "body123",
+		UserID: author.ID,
+		Status: "published",
+	}
+	err = db.Create(&post).Error
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", author.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+	c.Request, _ = http.NewRequest("PUT", "/posts/1", bytes.NewReader([]byte(`{"title": "it title","body": "it body","status": "draft"}`)))
+	postHandler.UpdatePost(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// สถานะในฐานข้อมูลต้องไม่เปลี่ยน
+	var stored models.Posts
+	err = db.First(&stored, post.PostID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "published", stored.Status)
+}
+
+func TestPatchPost(t *testing.T) {
+	// เตรียมฐานข้อมูล MySQL ในการเชื่อมต่อกับฐานข้อมูลที่ใช้ในการทดสอบ
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	// Run migrations สำหรับสร้างตาราง Posts
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	postHandler := handlers.NewPostHandler(db)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	err = db.Create(&author).Error
+	assert.NoError(t, err)
+	post := models.Posts{
+		Title:  "title123",
+		Body:   "short",
+		UserID: author.ID,
+		Status: "draft",
+	}
+	err = db.Create(&post).Error
+	assert.NoError(t, err)
+
+	patchPost := func(contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", author.Username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+		c.Request, _ = http.NewRequest("PATCH", "/posts/1", bytes.NewReader([]byte(body)))
+		c.Request.Header.Set("Content-Type", contentType)
+		postHandler.PatchPost(c)
+		return w
+	}
+
+	// merge patch แก้เฉพาะ title ส่วน body สั้นเดิมไม่ถูกตรวจซ้ำ
+	w := patchPost("application/merge-patch+json", `{"title": "patched title"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response handlers.CreatePostResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "patched title", response.Title)
+	assert.Equal(t, "short", response.Body)
+
+	// field ที่ส่งมาต้องผ่าน validation
+	w = patchPost("application/merge-patch+json", `{"title": "short"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// JSON Patch ที่ test ไม่ผ่านจะไม่แก้อะไรเลย
+	w = patchPost("application/json-patch+json", `[{"op": "test", "path": "/title", "value": "other title"}, {"op": "replace", "path": "/status", "value": "published"}]`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = patchPost("application/json-patch+json", `[{"op": "test", "path": "/title", "value": "patched title"}, {"op": "replace", "path": "/status", "value": "published"}]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stored models.Posts
+	err = db.First(&stored, post.PostID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "patched title", stored.Title)
+	assert.Equal(t, "published", stored.Status)
+	assert.NotNil(t, stored.PublishAt)
+}
+
+func TestPostETag(t *testing.T) {
+	// เตรียมฐานข้อมูล MySQL ในการเชื่อมต่อกับฐานข้อมูลที่ใช้ในการทดสอบ
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	// Run migrations สำหรับสร้างตาราง Posts
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	postHandler := handlers.NewPostHandler(db)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	err = db.Create(&author).Error
+	assert.NoError(t, err)
+	post := models.Posts{
+		Title:   "title123",
+		Body:    "body123",
+		UserID:  author.ID,
+		Status:  "published",
+		Version: 1,
+	}
+	err = db.Create(&post).Error
+	assert.NoError(t, err)
+	id := strconv.FormatUint(uint64(post.PostID), 10)
+
+	request := func(method string, header map[string]string, body string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", author.Username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: id})
+		c.Request, _ = http.NewRequest(method, "/posts/"+id, bytes.NewReader([]byte(body)))
+		for key, value := range header {
+			c.Request.Header.Set(key, value)
+		}
+		handle(c)
+		return w
+	}
+
+	w := request("GET", nil, "", postHandler.GetPost)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// ข้อมูลไม่เปลี่ยนตอบ 304
+	w = request("GET", map[string]string{"If-None-Match": etag}, "", postHandler.GetPost)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	body := `{"title": "it title","body": "it body","status": "published"}`
+	w = request("PUT", map[string]string{"If-Match": etag}, body, postHandler.UpdatePost)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// ETag เดิมใช้แก้ไขหรือลบไม่ได้แล้ว
+	w = request("PUT", map[string]string{"If-Match": etag}, body, postHandler.UpdatePost)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = request("DELETE", map[string]string{"If-Match": etag}, "", postHandler.DeletePost)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	var stored models.Posts
+	err = db.First(&stored, post.PostID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), stored.Version)
+}
+
+func TestPostVisibility(t *testing.T) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	author := models.Users{Username: "author", Fullname: "Post Author", Email: "author@example.com"}
+	follower := models.Users{Username: "follower", Fullname: "Post Follower", Email: "follower@example.com"}
+	stranger := models.Users{Username: "stranger", Fullname: "Post Stranger", Email: "stranger@example.com"}
+	for _, user := range []*models.Users{&author, &follower, &stranger} {
+		assert.NoError(t, db.Create(user).Error)
+	}
+	err = db.Create(&models.Follows{FollowingUserID: author.ID, FollowerUserID: follower.ID}).Error
+	assert.NoError(t, err)
+
+	for _, visibility := range []string{models.VisibilityPublic, models.VisibilityFollowers, models.VisibilityPrivate} {
+		post := models.Posts{Title: visibility, Body: "body123", UserID: author.ID, Status: "published", Visibility: visibility, Version: 1}
+		assert.NoError(t, db.Create(&post).Error)
+	}
+
+	postHandler := handlers.NewPostHandler(db)
+
+	list := func(username string) []string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		if username != "" {
+			c.Set("username", username)
+		}
+		c.Request, _ = http.NewRequest("GET", "/posts", nil)
+		postHandler.ListPosts(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response []handlers.CreatePostResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		titles := []string{}
+		for _, post := range response {
+			titles = append(titles, post.Title)
+		}
+		return titles
+	}
+
+	// ไม่ได้ login และคนที่ไม่ได้ติดตามเห็นเฉพาะ public ผู้ติดตามเห็น followers เพิ่ม เจ้าของเห็นทั้งหมด
+	assert.ElementsMatch(t, []string{"public"}, list(""))
+	assert.ElementsMatch(t, []string{"public"}, list(stranger.Username))
+	assert.ElementsMatch(t, []string{"public", "followers"}, list(follower.Username))
+	assert.ElementsMatch(t, []string{"public", "followers", "private"}, list(author.Username))
+
+	// feed ของผู้ติดตามมีโพสต์ที่มองเห็นได้ของคนที่ติดตาม
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", follower.Username)
+	c.Request, _ = http.NewRequest("GET", "/feed", nil)
+	postHandler.Feed(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var feed struct {
+		Items []handlers.CreatePostResponse `json:"items"`
+		Total int64                         `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+	assert.Equal(t, int64(2), feed.Total)
+}
+
+func TestUpdatePostOnlyAuthor(t *testing.T) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	admin := models.Users{Username: "admin_user", Fullname: "Admin User", Email: "admin@example.com", Role: models.RoleAdmin}
+	for _, user := range []*models.Users{&author, &other, &admin} {
+		assert.NoError(t, db.Create(user).Error)
+	}
+	post := models.Posts{Title: "title123", Body: "body123", UserID: author.ID, Status: "published", Version: 1}
+	assert.NoError(t, db.Create(&post).Error)
+
+	postHandler := handlers.NewPostHandler(db)
+	request := func(username, method, contentType, body string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+		c.Request, _ = http.NewRequest(method, "/posts/1", bytes.NewReader([]byte(body)))
+		c.Request.Header.Set("Content-Type", contentType)
+		handle(c)
+		return w
+	}
+
+	// ผู้ใช้อื่นแก้ไขโพสต์ไม่ได้ทั้ง PUT และ PATCH
+	w := request(other.Username, "PUT", "application/json", `{"title": "hijacked title","body": "hijacked","status": "published"}`, postHandler.UpdatePost)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = request(other.Username, "PATCH", "application/merge-patch+json", `{"visibility": "private"}`, postHandler.PatchPost)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var stored models.Posts
+	assert.NoError(t, db.First(&stored, post.PostID).Error)
+	assert.Equal(t, "title123", stored.Title)
+	assert.Equal(t, uint(1), stored.Version)
+
+	// admin แก้ไขได้
+	w = request(admin.Username, "PATCH", "application/merge-patch+json", `{"title": "moderated title"}`, postHandler.PatchPost)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRevisionsOnlyAuthor(t *testing.T) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	admin := models.Users{Username: "admin_user", Fullname: "Admin User", Email: "admin@example.com", Role: models.RoleAdmin}
+	for _, user := range []*models.Users{&author, &other, &admin} {
+		assert.NoError(t, db.Create(user).Error)
+	}
+	post := models.Posts{Title: "title123", Body: "body123", UserID: author.ID, Status: "published", Version: 1}
+	assert.NoError(t, db.Create(&post).Error)
+	revision := models.PostRevisions{PostID: post.PostID, Revision: 1, EditorID: author.ID, Title: "title123", Body: "removed secret", Status: "published"}
+	assert.NoError(t, db.Create(&revision).Error)
+
+	postHandler := handlers.NewPostHandler(db)
+	request := func(username, path string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)})
+		c.Request, _ = http.NewRequest("GET", path, nil)
+		handle(c)
+		return w
+	}
+
+	// ผู้ใช้อื่นมองเห็นโพสต์ได้แต่ดูประวัติการแก้ไขไม่ได้
+	w := request(other.Username, "/posts/1/revisions", postHandler.ListRevisions)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = request(other.Username, "/posts/1/revisions/diff?from=1", postHandler.DiffRevisions)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NotContains(t, w.Body.String(), "removed secret")
+
+	// เจ้าของและ admin ดูได้
+	for _, username := range []string{author.Username, admin.Username} {
+		w = request(username, "/posts/1/revisions", postHandler.ListRevisions)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = request(username, "/posts/1/revisions/diff?from=1", postHandler.DiffRevisions)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "-removed secret")
+	}
+}
+
+func TestScheduledPostPublish(t *testing.T) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	for _, user := range []*models.Users{&author, &other} {
+		assert.NoError(t, db.Create(user).Error)
+	}
+
+	postHandler := handlers.NewPostHandler(db)
+	publishAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	createPostJSON, _ := json.Marshal(map[string]interface{}{
+		"title":     "Scheduled post",
+		"body":      "hello @jane_doe",
+		"status":    "scheduled",
+		"publishAt": publishAt,
+	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", author.Username)
+	c.Request, _ = http.NewRequest("POST", "/posts", bytes.NewReader(createPostJSON))
+	postHandler.CreatePost(c)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created handlers.CreatePostResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	var stored models.Posts
+	assert.NoError(t, db.First(&stored, created.PostID).Error)
+	version := stored.Version
+
+	getPost := func() int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", other.Username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(created.PostID), 10)})
+		c.Request, _ = http.NewRequest("GET", "/posts/1", nil)
+		postHandler.GetPost(c)
+		return w.Code
+	}
+	mentionNotifications := func() int64 {
+		var count int64
+		db.Model(&models.Notifications{}).Where("userID = ? AND type = ?", other.ID, models.NotificationMention).Count(&count)
+		return count
+	}
+
+	// ตอนสร้างยังไม่แจ้งเตือนผู้ถูก mention และยังไม่ถึงเวลาโพสต์ยังถูกซ่อน
+	assert.Equal(t, int64(0), mentionNotifications())
+	mock := clock.NewMock()
+	mock.Set(publishAt.Add(-time.Minute))
+	publisher := scheduler.NewPostPublisher(db, time.Minute).WithClock(mock)
+	n, err := publisher.PublishDue()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	assert.Equal(t, http.StatusNotFound, getPost())
+	assert.Equal(t, int64(0), mentionNotifications())
+
+	// ถึงเวลาแล้วถูก publish ครั้งเดียวแม้รันซ้ำ
+	mock.Set(publishAt.Add(time.Minute))
+	n, err = publisher.PublishDue()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = publisher.PublishDue()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	assert.NoError(t, db.First(&stored, created.PostID).Error)
+	assert.Equal(t, models.PostStatusPublished, stored.Status)
+	assert.Equal(t, version+1, stored.Version)
+	assert.Equal(t, http.StatusOK, getPost())
+	assert.Equal(t, int64(1), mentionNotifications())
+}
+
+func TestDraftHiddenFromOthers(t *testing.T) {
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDBs(db)
+	err = db.AutoMigrate(testTables...)
+	assert.NoError(t, err)
+
+	author := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	for _, user := range []*models.Users{&author, &other} {
+		assert.NoError(t, db.Create(user).Error)
+	}
+	draft := models.Posts{Title: "secret draft", Body: "unpublished body", UserID: author.ID, Status: "draft", Version: 1}
+	assert.NoError(t, db.Create(&draft).Error)
+
+	postHandler := handlers.NewPostHandler(db)
+	request := func(method, contentType, body string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", other.Username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(draft.PostID), 10)})
+		c.Request, _ = http.NewRequest(method, "/posts/1", bytes.NewReader([]byte(body)))
+		c.Request.Header.Set("Content-Type", contentType)
+		handle(c)
+		return w
+	}
+
+	// คนอื่นไม่รู้ด้วยซ้ำว่ามี draft นี้ และไม่ได้เนื้อหาของ draft กลับไป
+	for _, w := range []*httptest.ResponseRecorder{
+		request("PUT", "application/json", `{"title": "other title","body": "other body","status": "draft"}`, postHandler.UpdatePost),
+		request("PATCH", "application/merge-patch+json", `{}`, postHandler.PatchPost),
+		request("PATCH", "application/merge-patch+json", `{"title": "other title"}`, postHandler.PatchPost),
+	} {
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NotContains(t, w.Body.String(), "secret draft")
+		assert.NotContains(t, w.Body.String(), "unpublished body")
+		assert.Empty(t, w.Header().Get("ETag"))
+	}
+
+	var stored models.Posts
+	assert.NoError(t, db.First(&stored, draft.PostID).Error)
+	assert.Equal(t, "secret draft", stored.Title)
+	assert.Equal(t, uint(1), stored.Version)
+}
+
+func TestRestoreRevisionAudit(t *testing.T) {
+	db, author, post := setupTestData(t)
+	revision := models.PostRevisions{PostID: post.PostID, Revision: 1, EditorID: author.ID, Title: "old title", Body: "old body", Status: "published"}
+	err := db.Create(&revision).Error
+	assert.NoError(t, err)
+
+	postHandler := handlers.NewPostHandler(db)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", author.Username)
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)}, {Key: "rev", Value: "1"}}
+	c.Request, _ = http.NewRequest("POST", "/posts/1/revisions/1/restore", nil)
+	postHandler.RestoreRevision(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// การกู้คืนถูกบันทึกเป็นการแก้ไขโพสต์ พร้อมค่าก่อนและหลัง
+	var logs []models.AuditLogs
+	err = db.Where("action = ? AND targetID = ?", audit.ActionPostUpdate, post.PostID).Find(&logs).Error
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, author.ID, logs[0].ActorID)
+		assert.JSONEq(t, `{"title": "title123", "body": "body123"}`, logs[0].Before)
+		assert.JSONEq(t, `{"title": "old title", "body": "old body"}`, logs[0].After)
+	}
 }
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// token ยืนยันการลบบัญชีใช้ได้ภายในเวลานี้
+const erasureTokenTTL = 15 * time.Minute
+
+type PrivacyHandler struct {
+	db          *gorm.DB
+	store       storage.Storage
+	gracePeriod time.Duration
+}
+
+func NewPrivacyHandler(db *gorm.DB, store storage.Storage) *PrivacyHandler {
+	return &PrivacyHandler{
+		db:          db,
+		store:       store,
+		gracePeriod: utils.DurationEnv("ACCOUNT_ERASURE_GRACE_PERIOD", 14*24*time.Hour),
+	}
+}
+
+type DataExportResponse struct {
+	ID          uint       `json:"id"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size"`
+	Error       string     `json:"error,omitempty"`
+	DownloadURL string     `json:"downloadURL,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+type ErasureRequest struct {
+	Password          string `json:"password" binding:"required"`
+	ConfirmationToken string `json:"confirmationToken"`
+}
+
+type ErasureResponse struct {
+	ConfirmationToken string     `json:"confirmationToken,omitempty"`
+	TokenExpiresAt    *time.Time `json:"tokenExpiresAt,omitempty"`
+	ConfirmedAt       *time.Time `json:"confirmedAt"`
+	EraseAt           *time.Time `json:"eraseAt"`
+}
+
+func newDataExportResponse(export models.DataExports) DataExportResponse {
+	response := DataExportResponse{
+		ID:          export.ID,
+		Status:      export.Status,
+		Size:        export.Size,
+		Error:       export.Error,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+	if export.Status == models.ExportStatusReady {
+		response.DownloadURL = fmt.Sprintf("/users/me/exports/%d/download", export.ID)
+	}
+	return response
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// POST /users/me/export ขอไฟล์ข้อมูลส่วนตัว ไฟล์ถูกสร้างใน background
+// ถ้ามีคำขอที่ยังสร้างไม่เสร็จอยู่แล้วจะคืนคำขอเดิม
+func (h *PrivacyHandler) RequestExport(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	var export models.DataExports
+	result := h.db.Where("userID = ? AND status IN ?", user.ID, []string{models.ExportStatusPending, models.ExportStatusProcessing}).
+		First(&export)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		export = models.DataExports{UserID: user.ID, Status: models.ExportStatusPending}
+		result = h.db.Create(&export)
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/users/me/exports/%d", export.ID))
+	c.JSON(http.StatusAccepted, newDataExportResponse(export))
+}
+
+// หาคำขอส่งออกของผู้ใช้ที่ login อยู่ ถ้าไม่เจอจะตอบ 404 และคืน false
+func (h *PrivacyHandler) findExport(c *gin.Context) (models.DataExports, bool) {
+	var export models.DataExports
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return export, false
+	}
+
+	result := h.db.Where("userID = ?", user.ID).First(&export, c.Param("exportID"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+		return export, false
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return export, false
+	}
+	return export, true
+}
+
+// GET /users/me/exports/:exportID
+func (h *PrivacyHandler) GetExport(c *gin.Context) {
+	export, ok := h.findExport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newDataExportResponse(export))
+}
+
+// GET /users/me/exports/:exportID/download
+func (h *PrivacyHandler) DownloadExport(c *gin.Context) {
+	export, ok := h.findExport(c)
+	if !ok {
+		return
+	}
+	if export.Status != models.ExportStatusReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "export is " + export.Status})
+		return
+	}
+
+	blob, err := h.store.Get(c.Request.Context(), export.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusGone, gin.H{"error": "export has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%d.zip"`, export.ID))
+	c.Header("Content-Length", fmt.Sprint(export.Size))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, blob)
+}
+
+// DELETE /users/me ลบบัญชีแบบสองขั้นตอน
+// ครั้งแรกส่งแค่รหัสผ่านจะได้ confirmationToken ครั้งที่สองส่ง token กลับมาเพื่อยืนยัน
+// บัญชีจะถูกลบจริงเมื่อพ้นช่วง grace period ระหว่างนั้นยกเลิกได้ที่ DELETE /users/me/erasure
+func (h *PrivacyHandler) RequestErasure(c *gin.Context) {
+	var req ErasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	if !utils.ComparePasswords(user.HashedPassword, req.Password) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "password is incorrect"})
+		return
+	}
+
+	var erasure models.AccountErasures
+	result := h.db.Where("userID = ?", user.ID).First(&erasure)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if erasure.ConfirmedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "account erasure is already scheduled"})
+		return
+	}
+
+	now := time.Now()
+	if req.ConfirmationToken == "" {
+		token, err := utils.GenerateToken(16)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		erasure = models.AccountErasures{
+			UserID:         user.ID,
+			TokenHash:      hashToken(token),
+			TokenExpiresAt: now.Add(erasureTokenTTL),
+		}
+		err = h.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&erasure).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, ErasureResponse{
+			ConfirmationToken: token,
+			TokenExpiresAt:    &erasure.TokenExpiresAt,
+		})
+		return
+	}
+
+	if erasure.UserID == 0 || now.After(erasure.TokenExpiresAt) ||
+		subtle.ConstantTimeCompare([]byte(erasure.TokenHash), []byte(hashToken(req.ConfirmationToken))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "confirmation token is invalid or expired"})
+		return
+	}
+
+	// ยกเลิก session อื่น แต่คง session นี้ไว้ให้ยกเลิกคำขอได้
+	eraseAt := now.Add(h.gracePeriod)
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&erasure).Updates(map[string]interface{}{
+			"confirmed_at": now,
+			"erase_at":     eraseAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		_, err = revokeOtherSessions(tx, user.ID, c.GetString("sid"))
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, ErasureResponse{ConfirmedAt: &now, EraseAt: &eraseAt})
+}
+
+// GET /users/me/erasure สถานะคำขอลบบัญชี
+func (h *PrivacyHandler) GetErasure(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	var erasure models.AccountErasures
+	result := h.db.Where("userID = ? AND confirmed_at IS NOT NULL", user.ID).First(&erasure)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no account erasure is scheduled"})
+		return
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ErasureResponse{ConfirmedAt: erasure.ConfirmedAt, EraseAt: erasure.EraseAt})
+}
+
+// DELETE /users/me/erasure ยกเลิกคำขอลบบัญชีระหว่าง grace period
+func (h *PrivacyHandler) CancelErasure(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	result := h.db.Where("userID = ?", user.ID).Delete(&models.AccountErasures{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no account erasure is scheduled"})
+	} else {
+		c.JSON(http.StatusNoContent, gin.H{"Success": "cancelled account erasure"})
+	}
+}
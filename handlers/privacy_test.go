@@ -0,0 +1,214 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/scheduler"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/benbjohnson/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// ตารางที่เกี่ยวกับข้อมูลส่วนตัวของผู้ใช้
+var privacyTables = []interface{}{
+	&models.Follows{},
+	&models.Sessions{},
+	&models.DataExports{},
+	&models.AccountErasures{},
+}
+
+func TestDataExport(t *testing.T) {
+	db, user, post := setupTestData(t)
+	db.Migrator().DropTable(privacyTables...)
+	err := db.AutoMigrate(privacyTables...)
+	assert.NoError(t, err)
+
+	err = db.Create(&models.Comments{PostID: post.PostID, UserID: user.ID, Body: "my comment"}).Error
+	assert.NoError(t, err)
+
+	store, err := storage.NewLocal(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	privacyHandler := handlers.NewPrivacyHandler(db, store)
+
+	request := func(method string, exportID uint, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", user.Username)
+		c.Params = append(c.Params, gin.Param{Key: "exportID", Value: strconv.FormatUint(uint64(exportID), 10)})
+		c.Request, _ = http.NewRequest(method, "/users/me/export", nil)
+		handle(c)
+		return w
+	}
+
+	w := request("POST", 0, privacyHandler.RequestExport)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var export handlers.DataExportResponse
+	err = json.Unmarshal(w.Body.Bytes(), &export)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ExportStatusPending, export.Status)
+
+	// ยังสร้างไม่เสร็จดาวน์โหลดไม่ได้
+	w = request("GET", export.ID, privacyHandler.DownloadExport)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	exporter := scheduler.NewDataExporter(db, store, time.Minute, time.Hour)
+	n, err := exporter.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	w = request("GET", export.ID, privacyHandler.DownloadExport)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"profile.json", "posts.json", "follows.json", "reactions.json", "comments.json"}, names)
+}
+
+func TestAccountErasure(t *testing.T) {
+	db, user, post := setupTestData(t)
+	db.Migrator().DropTable(privacyTables...)
+	err := db.AutoMigrate(privacyTables...)
+	assert.NoError(t, err)
+
+	password, err := utils.HashPassword("password123")
+	assert.NoError(t, err)
+	err = db.Model(&user).Update("hashedPassword", password).Error
+	assert.NoError(t, err)
+
+	// audit log ที่มีชื่อและอีเมลของผู้ใช้ ทั้งในฐานะผู้กระทำและผู้ถูกกระทำ
+	err = audit.Record(db, audit.Actor{UserID: user.ID, Username: user.Username, IP: "192.0.2.1"}, audit.Entry{
+		Action:     audit.ActionRegister,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		After:      map[string]interface{}{"username": user.Username, "email": user.Email},
+	})
+	assert.NoError(t, err)
+	err = audit.Record(db, audit.Actor{UserID: user.ID, Username: user.Username}, audit.Entry{
+		Action:     audit.ActionPostUpdate,
+		TargetType: audit.TargetPost,
+		TargetID:   post.PostID,
+		Before:     map[string]interface{}{"body": post.Body},
+	})
+	assert.NoError(t, err)
+
+	store, err := storage.NewLocal(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	privacyHandler := handlers.NewPrivacyHandler(db, store)
+
+	requestErasure := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", user.Username)
+		c.Request, _ = http.NewRequest("DELETE", "/users/me", bytes.NewReader([]byte(body)))
+		privacyHandler.RequestErasure(c)
+		return w
+	}
+
+	// รหัสผ่านผิด
+	w := requestErasure(`{"password": "wrong-password"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// ขั้นแรกได้ token สำหรับยืนยัน
+	w = requestErasure(`{"password": "password123"}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var pending handlers.ErasureResponse
+	err = json.Unmarshal(w.Body.Bytes(), &pending)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pending.ConfirmationToken)
+
+	w = requestErasure(`{"password": "password123", "confirmationToken": "wrong-token"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = requestErasure(`{"password": "password123", "confirmationToken": "` + pending.ConfirmationToken + `"}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var scheduled handlers.ErasureResponse
+	err = json.Unmarshal(w.Body.Bytes(), &scheduled)
+	assert.NoError(t, err)
+	assert.NotNil(t, scheduled.EraseAt)
+
+	// ระหว่าง grace period บัญชียังอยู่
+	mock := clock.NewMock()
+	mock.Set(time.Now())
+	eraser := scheduler.NewAccountEraser(db, store, time.Minute).WithClock(mock)
+	n, err := eraser.EraseDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// พ้น grace period ผู้ใช้และโพสต์ถูกลบถาวร
+	mock.Set(scheduled.EraseAt.Add(time.Second))
+	n, err = eraser.EraseDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var count int64
+	db.Unscoped().Model(&models.Users{}).Where("id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	db.Unscoped().Model(&models.Posts{}).Where("postID = ?", post.PostID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// audit log ยังอยู่แต่ไม่เหลือข้อมูลที่ระบุตัวผู้ใช้
+	var logs []models.AuditLogs
+	err = db.Where("actorID = ?", user.ID).Find(&logs).Error
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+	for _, log := range logs {
+		assert.Empty(t, log.ActorName)
+		assert.Empty(t, log.IP)
+		assert.Empty(t, log.Before)
+		assert.Empty(t, log.After)
+	}
+}
+
+func TestAccountErasureMissingUser(t *testing.T) {
+	db, user, _ := setupTestData(t)
+	db.Migrator().DropTable(privacyTables...)
+	err := db.AutoMigrate(privacyTables...)
+	assert.NoError(t, err)
+
+	// คำขอของผู้ใช้ที่ถูกลบไปก่อนแล้ว และคำขอของผู้ใช้ที่ยังอยู่
+	now := time.Now()
+	for _, userID := range []uint{user.ID + 100, user.ID} {
+		err = db.Create(&models.AccountErasures{
+			UserID:         userID,
+			TokenHash:      "hash",
+			TokenExpiresAt: now,
+			ConfirmedAt:    &now,
+			EraseAt:        &now,
+		}).Error
+		assert.NoError(t, err)
+	}
+
+	store, err := storage.NewLocal(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	mock := clock.NewMock()
+	mock.Set(now.Add(time.Second))
+	eraser := scheduler.NewAccountEraser(db, store, time.Minute).WithClock(mock)
+
+	// คำขอที่ไม่มีผู้ใช้ถูกลบทิ้ง และไม่ทำให้บัญชีถัดไปค้าง
+	n, err := eraser.EraseDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var count int64
+	db.Model(&models.AccountErasures{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	db.Unscoped().Model(&models.Users{}).Where("id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
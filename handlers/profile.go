@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ขนาดของรูปโปรไฟล์หลังย่อ
+const (
+	avatarSize   = 400
+	bannerWidth  = 1500
+	bannerHeight = 500
+)
+
+type ProfileHandler struct {
+	db            *gorm.DB
+	store         storage.Storage
+	maxUploadSize int64
+}
+
+func NewProfileHandler(db *gorm.DB, store storage.Storage) *ProfileHandler {
+	return &ProfileHandler{
+		db:            db,
+		store:         store,
+		maxUploadSize: int64(utils.IntEnv("MAX_UPLOAD_SIZE", 10<<20)),
+	}
+}
+
+type PublicProfileResponse struct {
+	CreateUserResponse
+	PostCount      int64 `json:"postCount"`
+	FollowerCount  int64 `json:"followerCount"`
+	FollowingCount int64 `json:"followingCount"`
+}
+
+// GET /users/by-username/:username อีเมลจะแสดงเฉพาะเจ้าของโปรไฟล์และผู้ดูแลระบบ
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
+	viewer, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	var user models.Users
+	result := h.db.Where("username = ?", c.Param("username")).First(&user)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	// ผู้ใช้ที่บล็อกกันมองไม่เห็นโปรไฟล์ของอีกฝ่าย
+	viewerID := viewer.ID
+	blocked, err := isBlocked(h.db, viewerID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	response := PublicProfileResponse{CreateUserResponse: newUserResponse(user, viewer)}
+
+	err = h.db.Model(&models.Posts{}).
+		Scopes(models.VisiblePosts(viewerID)).
+		Where("userID = ?", user.ID).
+		Count(&response.PostCount).Error
+	if err == nil {
+		err = h.db.Model(&models.Follows{}).
+			Where("followingUserID = ? AND status = ?", user.ID, models.FollowStatusAccepted).
+			Count(&response.FollowerCount).Error
+	}
+	if err == nil {
+		err = h.db.Model(&models.Follows{}).
+			Where("followerUserID = ? AND status = ?", user.ID, models.FollowStatusAccepted).
+			Count(&response.FollowingCount).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PUT /users/me/avatar
+func (h *ProfileHandler) UploadAvatar(c *gin.Context) {
+	h.uploadImage(c, "avatar", avatarSize, avatarSize)
+}
+
+// PUT /users/me/banner
+func (h *ProfileHandler) UploadBanner(c *gin.Context) {
+	h.uploadImage(c, "banner", bannerWidth, bannerHeight)
+}
+
+// DELETE /users/me/avatar
+func (h *ProfileHandler) DeleteAvatar(c *gin.Context) {
+	h.deleteImage(c, "avatar")
+}
+
+// DELETE /users/me/banner
+func (h *ProfileHandler) DeleteBanner(c *gin.Context) {
+	h.deleteImage(c, "banner")
+}
+
+// ครอปและย่อรูปให้ได้ขนาด width x height แล้วแทนที่รูปเดิมของผู้ใช้
+func (h *ProfileHandler) uploadImage(c *gin.Context, kind string, width, height int) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	data, contentType, ok := readImageUpload(c, h.maxUploadSize)
+	if !ok {
+		return
+	}
+
+	_, img, err := utils.SanitizeImage(data, contentType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image: " + err.Error()})
+		return
+	}
+
+	outType := utils.ThumbnailContentType(contentType)
+	resized, err := utils.EncodeImage(utils.ResizeToFill(img, width, height), outType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := fmt.Sprintf("users/%d/%s_%s%s", user.ID, kind, utils.GenerateRandomString(16), imageExtensions[outType])
+	if err := h.store.Put(c.Request.Context(), key, bytes.NewReader(resized), int64(len(resized)), outType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	oldKey := user.AvatarKey
+	if kind == "banner" {
+		oldKey = user.BannerKey
+	}
+
+	result := h.db.Model(&user).Updates(map[string]interface{}{
+		kind + "Key": key,
+		kind + "URL": h.store.URL(key),
+		"version":    gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		h.removeBlob(c, key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if oldKey != "" {
+		h.removeBlob(c, oldKey)
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user, user))
+}
+
+func (h *ProfileHandler) deleteImage(c *gin.Context, kind string) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	oldKey := user.AvatarKey
+	if kind == "banner" {
+		oldKey = user.BannerKey
+	}
+
+	result := h.db.Model(&user).Updates(map[string]interface{}{
+		kind + "Key": "",
+		kind + "URL": "",
+		"version":    gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if oldKey != "" {
+		h.removeBlob(c, oldKey)
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user, user))
+}
+
+// ลบไฟล์ใน storage ถ้าลบไม่สำเร็จแค่ log ไว้ เพราะแถวใน database ถูกจัดการไปแล้ว
+func (h *ProfileHandler) removeBlob(c *gin.Context, key string) {
+	if err := h.store.Delete(c.Request.Context(), key); err != nil {
+		log.Printf("profile: delete %s: %v", key, err)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProfileHidesEmailFromOthers(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์
+	db, user, _ := setupTestData(t)
+	err := db.AutoMigrate(&models.Follows{})
+	assert.NoError(t, err)
+
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	err = db.Create(&other).Error
+	assert.NoError(t, err)
+
+	store, err := storage.NewLocal(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	profileHandler := handlers.NewProfileHandler(db, store)
+
+	getProfile := func(viewer string) handlers.PublicProfileResponse {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", viewer)
+		c.Params = append(c.Params, gin.Param{Key: "username", Value: user.Username})
+		c.Request, _ = http.NewRequest("GET", "/users/by-username/"+user.Username, nil)
+		profileHandler.GetProfile(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response handlers.PublicProfileResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		return response
+	}
+
+	// ผู้ใช้อื่นไม่เห็นอีเมล
+	response := getProfile(other.Username)
+	assert.Equal(t, user.ID, response.ID)
+	assert.Equal(t, "", response.Email)
+	assert.Equal(t, int64(1), response.PostCount)
+
+	// เจ้าของโปรไฟล์เห็นอีเมลของตัวเอง
+	response = getProfile(user.Username)
+	assert.Equal(t, user.Email, response.Email)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reaction ที่ใช้ได้เสมอ emoji อื่นๆ ตั้งค่าผ่าน env REACTION_EMOJIS คั่นด้วย comma
+const reactionLike = "like"
+
+const defaultReactionEmojis = "❤️,😂,😮,😢,😡"
+
+type ReactionHandler struct {
+	db    *gorm.DB
+	types map[string]bool
+}
+
+func NewReactionHandler(db *gorm.DB) *ReactionHandler {
+	emojis := os.Getenv("REACTION_EMOJIS")
+	if emojis == "" {
+		emojis = defaultReactionEmojis
+	}
+
+	types := map[string]bool{reactionLike: true}
+	for _, emoji := range strings.Split(emojis, ",") {
+		if emoji = strings.TrimSpace(emoji); emoji != "" {
+			types[emoji] = true
+		}
+	}
+
+	return &ReactionHandler{
+		db:    db,
+		types: types,
+	}
+}
+
+type ReactionSummary struct {
+	Counts      map[string]int64 `json:"reactions"`
+	ReactedByMe []string         `json:"reactedByMe"`
+}
+
+type CreateReactionResponse struct {
+	UserID    uint      `json:"userID"`
+	Username  string    `json:"username"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// รวมจำนวน reaction แต่ละแบบของโพสต์ และ reaction ที่ผู้ใช้คนนี้กดไว้
+// นับจากแถวจริงทุกครั้ง จึงไม่มีตัวนับที่เพี้ยนเมื่อมี request พร้อมกัน
+func reactionSummaries(db *gorm.DB, postIDs []uint, viewerID uint) (map[uint]ReactionSummary, error) {
+	summaries := map[uint]ReactionSummary{}
+	for _, id := range postIDs {
+		summaries[id] = ReactionSummary{Counts: map[string]int64{}, ReactedByMe: []string{}}
+	}
+	if len(postIDs) == 0 {
+		return summaries, nil
+	}
+
+	var counts []struct {
+		PostID uint `gorm:"column:postID"`
+		Type   string
+		Total  int64
+	}
+	err := db.Model(&models.Reactions{}).
+		Select("postID, type, COUNT(*) AS total").
+		Where("postID IN ?", postIDs).
+		Group("postID, type").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		summaries[count.PostID].Counts[count.Type] = count.Total
+	}
+
+	if viewerID == 0 {
+		return summaries, nil
+	}
+
+	var mine []models.Reactions
+	err = db.Where("postID IN ? AND userID = ?", postIDs, viewerID).Order("type").Find(&mine).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, reaction := range mine {
+		summary := summaries[reaction.PostID]
+		summary.ReactedByMe = append(summary.ReactedByMe, reaction.Type)
+		summaries[reaction.PostID] = summary
+	}
+
+	return summaries, nil
+}
+
+func (h *ReactionHandler) respondSummary(c *gin.Context, postID, viewerID uint) {
+	summaries, err := reactionSummaries(h.db, []uint{postID}, viewerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summaries[postID])
+}
+
+// PUT /posts/:id/reactions/:type กดซ้ำได้โดยไม่เกิดแถวซ้ำ
+func (h *ReactionHandler) AddReaction(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	reactionType := c.Param("type")
+	if !h.types[reactionType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported reaction type"})
+		return
+	}
+
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return
+	}
+
+	reaction := models.Reactions{
+		PostID: post.PostID,
+		UserID: user.ID,
+		Type:   reactionType,
+	}
+
+	// ถ้ามีอยู่แล้ว unique index จะกันไม่ให้ insert ซ้ำ และไม่แจ้งเตือนซ้ำ
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		postID := post.PostID
+		err := events.Publish(tx, events.Event{
+			Type:    models.NotificationReaction,
+			UserID:  post.UserID,
+			ActorID: user.ID,
+			PostID:  &postID,
+		})
+		if err != nil {
+			return err
+		}
+		return events.Publish(tx, events.Event{Type: events.ReactionChanged, ActorID: user.ID, PostID: &postID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondSummary(c, post.PostID, user.ID)
+}
+
+// DELETE /posts/:id/reactions/:type ลบซ้ำได้โดยไม่ error
+func (h *ReactionHandler) RemoveReaction(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("postID = ? AND userID = ? AND type = ?", post.PostID, user.ID, c.Param("type")).
+			Delete(&models.Reactions{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		postID := post.PostID
+		return events.Publish(tx, events.Event{Type: events.ReactionChanged, ActorID: user.ID, PostID: &postID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondSummary(c, post.PostID, user.ID)
+}
+
+// GET /posts/:id/reactions?type=like&page=1&pageSize=20 รายชื่อผู้ที่กด reaction
+func (h *ReactionHandler) ListReactions(c *gin.Context) {
+	viewerID := currentUserID(c, h.db)
+	post, ok := findVisiblePost(c, h.db, viewerID)
+	if !ok {
+		return
+	}
+
+	// ไม่แสดง reaction ของผู้ใช้ที่บล็อกกันอยู่
+	pagination := parsePagination(c)
+	query := h.db.Table("reactions").
+		Select("reactions.userID AS user_id, users.username, reactions.type, reactions.created_at").
+		Joins("JOIN users ON users.id = reactions.userID AND users.deleted_at IS NULL").
+		Scopes(models.NotBlocked(viewerID, "reactions.userID")).
+		Where("reactions.postID = ?", post.PostID)
+	if reactionType := c.Query("type"); reactionType != "" {
+		query = query.Where("reactions.type = ?", reactionType)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []struct {
+		UserID    uint
+		Username  string
+		Type      string
+		CreatedAt time.Time
+	}
+	result := query.Order("reactions.created_at DESC, reactions.id DESC").
+		Offset(pagination.Offset()).
+		Limit(pagination.PageSize).
+		Scan(&rows)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := []CreateReactionResponse{}
+	for _, row := range rows {
+		response = append(response, CreateReactionResponse{
+			UserID:    row.UserID,
+			Username:  row.Username,
+			Type:      row.Type,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(response, total))
+}
@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddReactionIsIdempotent(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์
+	db, user, post := setupTestData(t)
+	reactionHandler := handlers.NewReactionHandler(db)
+
+	// กด like สองครั้ง ต้องนับเป็นครั้งเดียว
+	var summary handlers.ReactionSummary
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", user.Username)
+		c.Params = append(c.Params,
+			gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)},
+			gin.Param{Key: "type", Value: "like"},
+		)
+		c.Request, _ = http.NewRequest("PUT", "/posts/1/reactions/like", nil)
+		reactionHandler.AddReaction(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		err := json.Unmarshal(w.Body.Bytes(), &summary)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(1), summary.Counts["like"])
+	assert.Equal(t, []string{"like"}, summary.ReactedByMe)
+
+	var count int64
+	err := db.Model(&models.Reactions{}).Where("postID = ?", post.PostID).Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRemoveReaction(t *testing.T) {
+	db, user, post := setupTestData(t)
+	reactionHandler := handlers.NewReactionHandler(db)
+
+	reaction := models.Reactions{PostID: post.PostID, UserID: user.ID, Type: "like"}
+	err := db.Create(&reaction).Error
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params,
+		gin.Param{Key: "id", Value: strconv.FormatUint(uint64(post.PostID), 10)},
+		gin.Param{Key: "type", Value: "like"},
+	)
+	c.Request, _ = http.NewRequest("DELETE", "/posts/1/reactions/like", nil)
+	reactionHandler.RemoveReaction(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var summary handlers.ReactionSummary
+	err = json.Unmarshal(w.Body.Bytes(), &summary)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), summary.Counts["like"])
+	assert.Empty(t, summary.ReactedByMe)
+}
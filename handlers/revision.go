@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateRevisionResponse struct {
+	Revision  uint      `json:"revision"`
+	PostID    uint      `json:"postID"`
+	EditorID  uint      `json:"editorID"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newRevisionResponse(rev models.PostRevisions) CreateRevisionResponse {
+	return CreateRevisionResponse{
+		Revision:  rev.Revision,
+		PostID:    rev.PostID,
+		EditorID:  rev.EditorID,
+		Title:     rev.Title,
+		Body:      rev.Body,
+		Status:    rev.Status,
+		CreatedAt: rev.CreatedAt,
+	}
+}
+
+// บันทึกค่าปัจจุบันของโพสต์เป็น revision ใหม่ ต้องเรียกก่อนแก้ไขโพสต์ภายใน transaction เดียวกัน
+func saveRevision(tx *gorm.DB, post models.Posts, editorID uint) error {
+	var last uint
+	err := tx.Model(&models.PostRevisions{}).
+		Where("postID = ?", post.PostID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+
+	rev := models.PostRevisions{
+		PostID:   post.PostID,
+		Revision: last + 1,
+		EditorID: editorID,
+		Title:    post.Title,
+		Body:     post.Body,
+		Status:   post.Status,
+	}
+	return tx.Create(&rev).Error
+}
+
+// ข้อความของ revision ที่ใช้ทำ diff
+func revisionText(title, body, status string) string {
+	return fmt.Sprintf("title: %s\nstatus: %s\n\n%s", title, status, body)
+}
+
+func (h *PostHandler) findRevision(c *gin.Context, postID uint, rev string) (models.PostRevisions, bool) {
+	var revision models.PostRevisions
+	number, err := strconv.ParseUint(rev, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return revision, false
+	}
+
+	result := h.db.Where("postID = ? AND revision = ?", postID, number).First(&revision)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+		return revision, false
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return revision, false
+	}
+	return revision, true
+}
+
+// โพสต์ที่ผู้ใช้ดูประวัติการแก้ไขได้ เฉพาะเจ้าของหรือ admin เพราะ revision เก่าอาจมีเนื้อหาที่ลบออกไปแล้ว
+func (h *PostHandler) findRevisionPost(c *gin.Context) (models.Posts, bool) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return models.Posts{}, false
+	}
+	post, ok := findVisiblePost(c, h.db, user.ID)
+	if !ok {
+		return post, false
+	}
+	if post.UserID != user.ID && !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can view revisions"})
+		return post, false
+	}
+	return post, true
+}
+
+func (h *PostHandler) ListRevisions(c *gin.Context) {
+	post, ok := h.findRevisionPost(c)
+	if !ok {
+		return
+	}
+
+	var revisions []models.PostRevisions
+	result := h.db.Where("postID = ?", post.PostID).Order("revision DESC").Find(&revisions)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response := []CreateRevisionResponse{}
+	for _, rev := range revisions {
+		response = append(response, newRevisionResponse(rev))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /posts/:id/revisions/diff?from=1&to=2 ถ้าไม่ใส่ to จะเทียบกับโพสต์ปัจจุบัน
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	post, ok := h.findRevisionPost(c)
+	if !ok {
+		return
+	}
+
+	from, ok := h.findRevision(c, post.PostID, c.Query("from"))
+	if !ok {
+		return
+	}
+
+	toName := "current"
+	toText := revisionText(post.Title, post.Body, post.Status)
+	if c.Query("to") != "" {
+		to, ok := h.findRevision(c, post.PostID, c.Query("to"))
+		if !ok {
+			return
+		}
+		toName = fmt.Sprintf("revision %d", to.Revision)
+		toText = revisionText(to.Title, to.Body, to.Status)
+	}
+
+	diff, err := utils.LineDiff(
+		fmt.Sprintf("revision %d", from.Revision), toName,
+		revisionText(from.Title, from.Body, from.Status), toText,
+	)
+	if errors.Is(err, utils.ErrDiffTooLarge) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.String(http.StatusOK, diff)
+}
+
+// กู้คืนชื่อและเนื้อหาของโพสต์จาก revision เก่า สถานะยังคงเป็นไปตาม lifecycle ปัจจุบัน
+func (h *PostHandler) RestoreRevision(c *gin.Context) {
+	editorID := currentUserID(c, h.db)
+	post, ok := findVisiblePost(c, h.db, editorID)
+	if !ok {
+		return
+	}
+
+	if post.UserID != editorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can restore a revision"})
+		return
+	}
+
+	if !checkIfMatch(c, postETag(post.PostID, post.Version)) {
+		return
+	}
+
+	revision, ok := h.findRevision(c, post.PostID, c.Param("rev"))
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"title": revision.Title,
+		"body":  revision.Body,
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveRevision(tx, post, editorID); err != nil {
+			return err
+		}
+		if err := auditPostUpdate(tx, c, post, updates); err != nil {
+			return err
+		}
+		if err := updateVersioned(tx, &post, post.Version, updates); err != nil {
+			return err
+		}
+		return syncPostEntities(tx, post)
+	})
+	if err != nil {
+		respondSaveError(c, err)
+		return
+	}
+
+	h.respondStoredPost(c, post.PostID)
+}
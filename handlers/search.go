@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/NopparootSuree/go-social/search"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SearchHandler struct {
+	db     *gorm.DB
+	engine search.Engine
+}
+
+func NewSearchHandler(db *gorm.DB, engine search.Engine) *SearchHandler {
+	return &SearchHandler{
+		db:     db,
+		engine: engine,
+	}
+}
+
+type CreateSearchResponse struct {
+	Posts *PageResponse `json:"posts,omitempty"`
+	Users *PageResponse `json:"users,omitempty"`
+}
+
+// GET /search?q=...&type=all|posts|users&page=1&pageSize=20
+func (h *SearchHandler) Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing search query"})
+		return
+	}
+
+	searchType := c.DefaultQuery("type", "all")
+	if searchType != "all" && searchType != "posts" && searchType != "users" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be all, posts or users"})
+		return
+	}
+
+	pagination := parsePagination(c)
+	query := search.Query{
+		Text:     text,
+		ViewerID: currentUserID(c, h.db),
+		Offset:   pagination.Offset(),
+		Limit:    pagination.PageSize,
+	}
+
+	var response CreateSearchResponse
+	if searchType != "users" {
+		hits, total, err := h.engine.SearchPosts(c.Request.Context(), query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		posts := pagination.Response(hits, total)
+		response.Posts = &posts
+	}
+
+	if searchType != "posts" {
+		hits, total, err := h.engine.SearchUsers(c.Request.Context(), query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		users := pagination.Response(hits, total)
+		response.Users = &users
+	}
+
+	c.JSON(http.StatusOK, response)
+}
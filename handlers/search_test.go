@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/search"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchHidesDrafts(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์ แล้วเพิ่มโพสต์ draft ของผู้ใช้อื่น
+	db, user, _ := setupTestData(t)
+
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	err := db.Create(&other).Error
+	assert.NoError(t, err)
+
+	published := models.Posts{Title: "Golang tips", Body: "golang makes concurrency easy", UserID: other.ID, Status: "published"}
+	err = db.Create(&published).Error
+	assert.NoError(t, err)
+	draft := models.Posts{Title: "Golang draft", Body: "unfinished golang notes", UserID: other.ID, Status: "draft"}
+	err = db.Create(&draft).Error
+	assert.NoError(t, err)
+
+	engine := search.NewMemoryEngine(db)
+	err = engine.Rebuild(context.Background())
+	assert.NoError(t, err)
+	searchHandler := handlers.NewSearchHandler(db, engine)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Request, _ = http.NewRequest("GET", "/search?q=golang&type=posts", nil)
+	searchHandler.Search(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Posts struct {
+			Items []search.PostHit `json:"items"`
+			Total int64            `json:"total"`
+		} `json:"posts"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	// draft ของผู้อื่นต้องไม่ถูกค้นเจอ และคำที่ตรงถูกไฮไลต์
+	assert.Equal(t, int64(1), response.Posts.Total)
+	assert.Len(t, response.Posts.Items, 1)
+	assert.Equal(t, published.PostID, response.Posts.Items[0].PostID)
+	assert.Equal(t, "<mark>Golang</mark> tips", response.Posts.Items[0].Title)
+}
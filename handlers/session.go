@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"gorm.io/gorm"
+)
+
+// สร้าง session ใหม่ให้การ login หนึ่งครั้ง
+func createSession(db *gorm.DB, userID uint, ttl time.Duration) (models.Sessions, error) {
+	id, err := utils.GenerateToken(32)
+	if err != nil {
+		return models.Sessions{}, err
+	}
+
+	session := models.Sessions{
+		ID:        id,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	err = db.Create(&session).Error
+	return session, err
+}
+
+// ยกเลิก session อื่นของผู้ใช้ทั้งหมด ยกเว้น session ที่ใช้อยู่
+func revokeOtherSessions(tx *gorm.DB, userID uint, keepID string) (int64, error) {
+	result := tx.Model(&models.Sessions{}).
+		Where("userID = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
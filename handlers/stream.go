@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NopparootSuree/go-social/realtime"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+// เวลาที่รอให้เขียนข้อความลง WebSocket เสร็จ
+const streamWriteTimeout = 10 * time.Second
+
+type StreamHandler struct {
+	db        *gorm.DB
+	hub       *realtime.Hub
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+}
+
+func NewStreamHandler(db *gorm.DB, hub *realtime.Hub) *StreamHandler {
+	return &StreamHandler{
+		db:        db,
+		hub:       hub,
+		heartbeat: utils.DurationEnv("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+	}
+}
+
+// id ของข้อความล่าสุดที่ client ได้รับ จาก header Last-Event-ID หรือ ?lastEventID=
+func lastEventID(c *gin.Context) uint64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("lastEventID")
+	}
+	id, _ := strconv.ParseUint(value, 10, 64)
+	return id
+}
+
+func writeSSE(w io.Writer, msg realtime.Message) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Data)
+}
+
+// GET /stream Server-Sent Events ส่ง feed การแจ้งเตือน และจำนวน reaction แบบ real-time
+// ส่ง comment ping ทุก heartbeat เพื่อไม่ให้ proxy ตัดการเชื่อมต่อ
+func (h *StreamHandler) Stream(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	client, backlog := h.hub.Subscribe(user.ID, lastEventID(c))
+	defer h.hub.Unsubscribe(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, msg := range backlog {
+		writeSSE(c.Writer, msg)
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, ok := <-client.C:
+			if !ok {
+				return
+			}
+			writeSSE(c.Writer, msg)
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// GET /stream/ws ข้อความชุดเดียวกับ /stream ผ่าน WebSocket ในรูป JSON
+// ส่ง ping frame ทุก heartbeat และตัดการเชื่อมต่อถ้าไม่ได้ pong กลับภายในสองรอบ
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade ตอบ error ให้ client ไปแล้ว
+		return
+	}
+	defer conn.Close()
+
+	client, backlog := h.hub.Subscribe(user.ID, lastEventID(c))
+	defer h.hub.Unsubscribe(client)
+
+	// อ่านเพื่อรับ pong และ close frame ข้อความจาก client ไม่ได้ใช้
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(msg realtime.Message) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}
+
+	for _, msg := range backlog {
+		if err := write(msg); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case msg, ok := <-client.C:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect with lastEventID"),
+					time.Now().Add(streamWriteTimeout))
+				return
+			}
+			if err := write(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
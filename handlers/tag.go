@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagHandler struct {
+	db *gorm.DB
+}
+
+func NewTagHandler(db *gorm.DB) *TagHandler {
+	return &TagHandler{
+		db: db,
+	}
+}
+
+type MentionResponse struct {
+	UserID   uint   `json:"userID"`
+	Username string `json:"username"`
+}
+
+// แยก hashtag และ mention จากชื่อเรื่องและเนื้อหาของโพสต์ แล้วบันทึกลงตาราง ต้องเรียกภายใน transaction
+// mention ที่มีอยู่เดิมจะถูกเก็บไว้เพื่อไม่ให้แจ้งเตือนซ้ำเมื่อแก้ไขโพสต์
+func syncPostEntities(tx *gorm.DB, post models.Posts) error {
+	text := post.Title + "\n" + post.Body
+
+	if err := tx.Where("postID = ?", post.PostID).Delete(&models.PostTags{}).Error; err != nil {
+		return err
+	}
+	if names := utils.ExtractHashtags(text); len(names) > 0 {
+		tags := make([]models.Tags, 0, len(names))
+		for _, name := range names {
+			tags = append(tags, models.Tags{Name: name})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+
+		var tagIDs []uint
+		if err := tx.Model(&models.Tags{}).Where("name IN ?", names).Pluck("id", &tagIDs).Error; err != nil {
+			return err
+		}
+		postTags := make([]models.PostTags, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			postTags = append(postTags, models.PostTags{PostID: post.PostID, TagID: tagID})
+		}
+		if err := tx.Create(&postTags).Error; err != nil {
+			return err
+		}
+	}
+
+	var userIDs []uint
+	if usernames := utils.ExtractMentions(text); len(usernames) > 0 {
+		// ผู้ใช้ที่บล็อกกับผู้เขียนจะไม่ถูก mention
+		err := tx.Model(&models.Users{}).
+			Scopes(models.NotBlocked(post.UserID, "users.id")).
+			Where("username IN ?", usernames).
+			Pluck("id", &userIDs).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	removed := tx.Where("postID = ?", post.PostID)
+	if len(userIDs) > 0 {
+		removed = removed.Where("userID NOT IN ?", userIDs)
+	}
+	if err := removed.Delete(&models.Mentions{}).Error; err != nil {
+		return err
+	}
+
+	if len(userIDs) > 0 {
+		mentions := make([]models.Mentions, 0, len(userIDs))
+		for _, userID := range userIDs {
+			mentions = append(mentions, models.Mentions{PostID: post.PostID, UserID: userID})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&mentions).Error; err != nil {
+			return err
+		}
+	}
+
+	return events.NotifyMentions(tx, post)
+}
+
+// GET /tags/:tag/posts โพสต์ล่าสุดที่มี hashtag นี้
+func (h *TagHandler) ListTagPosts(c *gin.Context) {
+	viewerID := currentUserID(c, h.db)
+	pagination := parsePagination(c)
+	tag := strings.ToLower(strings.TrimPrefix(c.Param("tag"), "#"))
+
+	query := h.db.Model(&models.Posts{}).
+		Joins("JOIN post_tags ON post_tags.postID = posts.postID").
+		Joins("JOIN tags ON tags.id = post_tags.tagID").
+		Where("tags.name = ?", tag).
+		Scopes(models.VisiblePosts(viewerID)).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var posts []models.Posts
+	result := query.Select("posts.*").Order("posts.created_at DESC").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&posts)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	response, err := postResponses(h.db, posts, viewerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(response, total))
+}
@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePostExtractsTagsAndMentions(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์ แล้วเพิ่มผู้ใช้ที่จะถูก mention
+	db, user, _ := setupTestData(t)
+
+	mentioned := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	err := db.Create(&mentioned).Error
+	assert.NoError(t, err)
+
+	postHandler := handlers.NewPostHandler(db)
+
+	createPostReq := handlers.CreatePostRequest{
+		Title:  "Weekend trip",
+		Body:   "Hiking with @jane_doe and @nobody_here #Travel #travel #outdoors",
+		Status: "published",
+	}
+	createPostJSON, _ := json.Marshal(createPostReq)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Request, _ = http.NewRequest("POST", "/posts", bytes.NewReader(createPostJSON))
+	postHandler.CreatePost(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response handlers.CreatePostResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	// hashtag ถูก normalize และไม่ซ้ำ ส่วน mention ที่ไม่มีผู้ใช้อยู่จริงถูกข้ามไป
+	assert.Equal(t, []string{"outdoors", "travel"}, response.Tags)
+	assert.Equal(t, []handlers.MentionResponse{{UserID: mentioned.ID, Username: "jane_doe"}}, response.Mentions)
+
+	// ผู้ที่ถูก mention ได้รับการแจ้งเตือน
+	var notifications []models.Notifications
+	err = db.Where("userID = ?", mentioned.ID).Find(&notifications).Error
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, models.NotificationMention, notifications[0].Type)
+	assert.Equal(t, user.ID, notifications[0].ActorID)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// โพสต์ในถังขยะ พร้อมเวลาที่จะถูกลบถาวร
+type TrashedPostResponse struct {
+	CreatePostResponse
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+// ผู้ใช้ในถังขยะ พร้อมเวลาที่จะถูกลบถาวร
+type TrashedUserResponse struct {
+	CreateUserResponse
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+// ลบผู้ใช้ลงถังขยะ โพสต์ที่ยังไม่ถูกลบจะถูกลบไปพร้อมกันด้วยเวลาเดียวกัน เพื่อกู้คืนพร้อมผู้ใช้ได้
+// การติดตามทั้งสองทางถูกลบทันทีและไม่กลับมาเมื่อกู้คืน session ทั้งหมดถูกยกเลิก
+func trashUser(tx *gorm.DB, user models.Users, now time.Time) error {
+	result := tx.Model(&user).Where("version = ?", user.Version).Update("deleted_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+
+	err := tx.Model(&models.Posts{}).Where("userID = ?", user.ID).Update("deleted_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Where("followerUserID = ? OR followingUserID = ?", user.ID, user.ID).Delete(&models.Follows{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Sessions{}).
+		Where("userID = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", now).Error
+}
+
+// GET /posts/trash เจ้าของเห็นเฉพาะโพสต์ของตัวเอง admin เห็นทั้งหมดหรือกรองด้วย ?userID=
+func (h *PostHandler) ListTrash(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	query := h.db.Unscoped().Model(&models.Posts{}).Where("deleted_at IS NOT NULL")
+	if !user.IsAdmin() {
+		query = query.Where("userID = ?", user.ID)
+	} else if userID := c.Query("userID"); userID != "" {
+		query = query.Where("userID = ?", userID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var posts []models.Posts
+	err = query.Order("deleted_at DESC").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&posts).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	retention := utils.TrashRetention()
+	items := make([]TrashedPostResponse, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, TrashedPostResponse{
+			CreatePostResponse: newPostResponse(post),
+			DeletedAt:          post.DeletedAt.Time,
+			PurgeAt:            post.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
+
+// POST /posts/:id/restore กู้คืนโพสต์จากถังขยะ เฉพาะเจ้าของหรือ admin
+func (h *PostHandler) RestorePost(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	var post models.Posts
+	result := h.db.Unscoped().Where("deleted_at IS NOT NULL").First(&post, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post is not in trash"})
+		return
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if post.UserID != user.ID && !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can restore this post"})
+		return
+	}
+
+	// โพสต์ของผู้ใช้ที่อยู่ในถังขยะต้องกู้คืนผ่านการกู้คืนผู้ใช้
+	var author int64
+	if err := h.db.Model(&models.Users{}).Where("id = ?", post.UserID).Count(&author).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if author == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "the author's account is deleted"})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&post).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, user), audit.Entry{
+			Action:     audit.ActionPostRestore,
+			TargetType: audit.TargetPost,
+			TargetID:   post.PostID,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondStoredPost(c, post.PostID)
+}
+
+// GET /users/trash เฉพาะ admin
+func (h *UserHandler) ListTrash(c *gin.Context) {
+	admin, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+	if !admin.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	query := h.db.Unscoped().Model(&models.Users{}).Where("deleted_at IS NOT NULL").Session(&gorm.Session{})
+
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var users []models.Users
+	err = query.Order("deleted_at DESC").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&users).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	retention := utils.TrashRetention()
+	items := make([]TrashedUserResponse, 0, len(users))
+	for _, user := range users {
+		items = append(items, TrashedUserResponse{
+			CreateUserResponse: newUserResponse(user, admin),
+			DeletedAt:          user.DeletedAt.Time,
+			PurgeAt:            user.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
+
+// POST /users/:id/restore เฉพาะ admin กู้คืนผู้ใช้พร้อมโพสต์ที่ถูกลบไปพร้อมกัน
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	admin, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+	if !admin.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	var user models.Users
+	result := h.db.Unscoped().Where("deleted_at IS NOT NULL").First(&user, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user is not in trash"})
+		return
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.Posts{}).
+			Where("userID = ? AND deleted_at = ?", user.ID, user.DeletedAt.Time).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, admin), audit.Entry{
+			Action:     audit.ActionUserRestore,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	user.DeletedAt = gorm.DeletedAt{}
+	c.JSON(http.StatusOK, newUserResponse(user, admin))
+}
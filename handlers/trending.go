@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/trending"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TrendingHandler struct {
+	db         *gorm.DB
+	aggregator *trending.Aggregator
+}
+
+func NewTrendingHandler(db *gorm.DB, aggregator *trending.Aggregator) *TrendingHandler {
+	return &TrendingHandler{
+		db:         db,
+		aggregator: aggregator,
+	}
+}
+
+type TrendingPostResponse struct {
+	CreatePostResponse
+	Score float64 `json:"score"`
+}
+
+type CreateTrendingResponse struct {
+	Window   trending.Window        `json:"window"`
+	Hashtags []trending.TagScore    `json:"hashtags"`
+	Posts    []TrendingPostResponse `json:"posts"`
+}
+
+// GET /trending?window=hour|day|week&limit=10 อ่านจาก cache ของ aggregator เท่านั้น
+func (h *TrendingHandler) GetTrending(c *gin.Context) {
+	window, ok := trending.ParseWindow(c.DefaultQuery("window", string(trending.WindowDay)))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be hour, day or week"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > trending.MaxItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	snapshot := h.aggregator.Snapshot(window)
+	c.Header("Last-Modified", snapshot.GeneratedAt.UTC().Format(http.TimeFormat))
+
+	tags := snapshot.Tags
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+
+	scores := snapshot.Posts
+	if len(scores) > limit {
+		scores = scores[:limit]
+	}
+	ids := make([]uint, 0, len(scores))
+	for _, score := range scores {
+		ids = append(ids, score.PostID)
+	}
+
+	// โหลดโพสต์ตามสิทธิ์ของผู้ดู แล้วเรียงตามอันดับเดิม
+	viewerID := currentUserID(c, h.db)
+	var posts []models.Posts
+	if len(ids) > 0 {
+		result := h.db.Scopes(models.VisiblePosts(viewerID)).Where("postID IN ?", ids).Find(&posts)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+	}
+
+	responses, err := postResponses(h.db, posts, viewerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID := map[uint]CreatePostResponse{}
+	for _, response := range responses {
+		byID[response.PostID] = response
+	}
+
+	response := CreateTrendingResponse{
+		Window:   window,
+		Hashtags: tags,
+		Posts:    []TrendingPostResponse{},
+	}
+	for _, score := range scores {
+		if post, ok := byID[score.PostID]; ok {
+			response.Posts = append(response.Posts, TrendingPostResponse{CreatePostResponse: post, Score: score.Score})
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,155 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/trending"
+	"github.com/benbjohnson/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTrending(t *testing.T) {
+	// เริ่มจากผู้ใช้หนึ่งคนและโพสต์หนึ่งโพสต์
+	db, user, quiet := setupTestData(t)
+
+	now := time.Now()
+	popular := models.Posts{Title: "Popular post", Body: "everyone likes this", UserID: user.ID, Status: "published", PublishAt: &now}
+	err := db.Create(&popular).Error
+	assert.NoError(t, err)
+	tag := models.Tags{Name: "golang"}
+	err = db.Create(&tag).Error
+	assert.NoError(t, err)
+	err = db.Create(&models.PostTags{PostID: popular.PostID, TagID: tag.ID}).Error
+	assert.NoError(t, err)
+
+	// โพสต์ยอดนิยมมีทั้ง reaction และความคิดเห็น
+	err = db.Create(&models.Reactions{PostID: popular.PostID, UserID: user.ID, Type: "like"}).Error
+	assert.NoError(t, err)
+	err = db.Create(&models.Comments{PostID: popular.PostID, UserID: user.ID, Body: "nice"}).Error
+	assert.NoError(t, err)
+
+	aggregator := trending.NewAggregator(db, time.Minute)
+	err = aggregator.Refresh(context.Background())
+	assert.NoError(t, err)
+	trendingHandler := handlers.NewTrendingHandler(db, aggregator)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Request, _ = http.NewRequest("GET", "/trending?window=day", nil)
+	trendingHandler.GetTrending(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response handlers.CreateTrendingResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, trending.WindowDay, response.Window)
+	assert.NotEmpty(t, response.Posts)
+	assert.Equal(t, popular.PostID, response.Posts[0].PostID)
+	assert.NotEqual(t, quiet.PostID, response.Posts[0].PostID)
+	assert.Len(t, response.Hashtags, 1)
+	assert.Equal(t, "golang", response.Hashtags[0].Tag)
+}
+
+func TestTrendingHidesTagsOfHiddenPosts(t *testing.T) {
+	db, user, _ := setupTestData(t)
+
+	hidden := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com", Private: true}
+	err := db.Create(&hidden).Error
+	assert.NoError(t, err)
+
+	now := time.Now()
+	public := models.Posts{Title: "Public post", Body: "hello", UserID: user.ID, Status: "published", PublishAt: &now}
+	private := models.Posts{Title: "Private post", Body: "secret", UserID: user.ID, Status: "published", Visibility: models.VisibilityPrivate, PublishAt: &now}
+	followers := models.Posts{Title: "Followers post", Body: "secret", UserID: user.ID, Status: "published", Visibility: models.VisibilityFollowers, PublishAt: &now}
+	privateAccount := models.Posts{Title: "Private account post", Body: "secret", UserID: hidden.ID, Status: "published", PublishAt: &now}
+	for _, post := range []*models.Posts{&public, &private, &followers, &privateAccount} {
+		assert.NoError(t, db.Create(post).Error)
+	}
+
+	// hashtag secret ถูกใช้เฉพาะในโพสต์ที่ผู้ที่ไม่ได้ login มองไม่เห็น แต่มีกิจกรรมมากกว่า
+	golang := models.Tags{Name: "golang"}
+	secret := models.Tags{Name: "secret"}
+	for _, tag := range []*models.Tags{&golang, &secret} {
+		assert.NoError(t, db.Create(tag).Error)
+	}
+	assert.NoError(t, db.Create(&models.PostTags{PostID: public.PostID, TagID: golang.ID}).Error)
+	for _, post := range []models.Posts{private, followers, privateAccount} {
+		assert.NoError(t, db.Create(&models.PostTags{PostID: post.PostID, TagID: secret.ID}).Error)
+		assert.NoError(t, db.Create(&models.Reactions{PostID: post.PostID, UserID: hidden.ID, Type: "like"}).Error)
+		assert.NoError(t, db.Create(&models.Comments{PostID: post.PostID, UserID: hidden.ID, Body: "shh"}).Error)
+	}
+
+	aggregator := trending.NewAggregator(db, time.Minute)
+	err = aggregator.Refresh(context.Background())
+	assert.NoError(t, err)
+
+	snapshot := aggregator.Snapshot(trending.WindowDay)
+	var tags []string
+	for _, tag := range snapshot.Tags {
+		tags = append(tags, tag.Tag)
+	}
+	assert.Equal(t, []string{"golang"}, tags)
+	if assert.Len(t, snapshot.Posts, 1) {
+		assert.Equal(t, public.PostID, snapshot.Posts[0].PostID)
+	}
+}
+
+func TestTrendingLateActivity(t *testing.T) {
+	db, user, _ := setupTestData(t)
+
+	mock := clock.NewMock()
+	mock.Set(time.Now().Truncate(time.Second))
+	aggregator := trending.NewAggregator(db, time.Minute).WithClock(mock)
+	score := func(postID uint) float64 {
+		for _, post := range aggregator.Snapshot(trending.WindowDay).Posts {
+			if post.PostID == postID {
+				return post.Score
+			}
+		}
+		return 0
+	}
+
+	// โพสต์ตั้งเวลาที่ถึงเวลาแล้ว แต่ PostPublisher ยังไม่ได้ publish ตอน refresh
+	publishAt := mock.Now().Add(-time.Minute)
+	scheduled := models.Posts{Title: "Scheduled post", Body: "soon", UserID: user.ID, Status: "scheduled", PublishAt: &publishAt}
+	postedAt := mock.Now().Add(-2 * time.Minute)
+	popular := models.Posts{Title: "Popular post", Body: "hello", UserID: user.ID, Status: "published", PublishAt: &postedAt}
+	for _, post := range []*models.Posts{&scheduled, &popular} {
+		assert.NoError(t, db.Create(post).Error)
+	}
+	err := db.Create(&models.Comments{ID: 10, PostID: popular.PostID, UserID: user.ID, Body: "first", CreatedAt: mock.Now().Add(-time.Minute)}).Error
+	assert.NoError(t, err)
+
+	assert.NoError(t, aggregator.Refresh(context.Background()))
+	assert.Zero(t, score(scheduled.PostID))
+	before := score(popular.PostID)
+
+	// publish หลัง refresh ไปแล้ว และความคิดเห็นที่ id น้อยกว่า commit ทีหลัง ต้องถูกนับในรอบถัดไป
+	assert.NoError(t, db.Model(&scheduled).Update("status", "published").Error)
+	err = db.Create(&models.Comments{ID: 5, PostID: popular.PostID, UserID: user.ID, Body: "late", CreatedAt: mock.Now().Add(-30 * time.Second)}).Error
+	assert.NoError(t, err)
+	mock.Add(time.Minute)
+	assert.NoError(t, aggregator.Refresh(context.Background()))
+
+	published := score(scheduled.PostID)
+	assert.NotZero(t, published)
+	after := score(popular.PostID)
+	assert.Greater(t, after, before)
+
+	// อ่านช่วงเวลาซ้อนกันแต่ไม่นับซ้ำ
+	mock.Add(time.Minute)
+	assert.NoError(t, aggregator.Refresh(context.Background()))
+	assert.InEpsilon(t, published, score(scheduled.PostID), 0.01)
+	assert.InEpsilon(t, after, score(popular.PostID), 0.01)
+}
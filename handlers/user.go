@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/NopparootSuree/go-social/audit"
 	"github.com/NopparootSuree/go-social/models"
 	"github.com/NopparootSuree/go-social/utils"
 	"github.com/gin-gonic/gin"
@@ -11,31 +14,86 @@ import (
 )
 
 type UserHandler struct {
-	db *gorm.DB
+	db     *gorm.DB
+	policy *utils.PasswordPolicy
 }
 
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{
-		db: db,
+// โหลด policy ไม่สำเร็จต้องไม่เริ่มระบบ เพื่อไม่ให้ตรวจรหัสผ่านแบบหละหลวม
+func NewUserHandler(db *gorm.DB) (*UserHandler, error) {
+	policy, err := utils.NewPasswordPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("load password policy: %w", err)
 	}
+
+	return &UserHandler{
+		db:     db,
+		policy: policy,
+	}, nil
 }
 
 type CreateUserResponse struct {
 	ID        uint      `json:"id" binding:"required"`
 	Username  string    `json:"username" binding:"required"`
 	FullName  string    `json:"fullName" binding:"required"`
-	Email     string    `json:"email" binding:"required,email"`
+	Email     string    `json:"email,omitempty"`
+	Bio       string    `json:"bio"`
+	Location  string    `json:"location"`
+	Website   string    `json:"website"`
+	AvatarURL string    `json:"avatarURL"`
+	BannerURL string    `json:"bannerURL"`
+	Private   bool      `json:"private"`
 	CreatedAt time.Time `json:"createdAt" binding:"required"`
 }
 
-type CreateUserUpdateRequest struct {
-	HashedPassword string `json:"hashedPassword" binding:"required,min=6"`
-	FullName       string `json:"fullName" binding:"required,min=6"`
+// field ของผู้ใช้ที่แก้ไขผ่าน PATCH ได้ รหัสผ่านต้องเปลี่ยนผ่าน PUT /users/me/password
+type UserPatch struct {
+	FullName string `json:"fullName" binding:"required,min=6"`
+	Bio      string `json:"bio" binding:"max=500"`
+	Location string `json:"location" binding:"max=100"`
+	Website  string `json:"website" binding:"omitempty,url,max=255"`
+	Private  bool   `json:"private"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+// อีเมลแสดงเฉพาะเจ้าของบัญชีและผู้ดูแลระบบ
+func newUserResponse(user, viewer models.Users) CreateUserResponse {
+	response := CreateUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		FullName:  user.Fullname,
+		Email:     user.Email,
+		Bio:       user.Bio,
+		Location:  user.Location,
+		Website:   user.Website,
+		AvatarURL: user.AvatarURL,
+		BannerURL: user.BannerURL,
+		Private:   user.Private,
+		CreatedAt: user.CreatedAt,
+	}
+	if viewer.ID != user.ID && !viewer.IsAdmin() {
+		response.Email = ""
+	}
+	return response
 }
 
 func (h *UserHandler) ListUsers(c *gin.Context) {
+	viewer, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	// ไม่แสดงผู้ใช้ที่บล็อกกันอยู่
 	var users []models.Users
-	result := h.db.Find(&users)
+	result := h.db.Scopes(models.NotBlocked(viewer.ID, "users.id")).Find(&users)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
@@ -49,13 +107,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	var response []CreateUserResponse
 
 	for _, user := range users {
-		response = append(response, CreateUserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			FullName:  user.Fullname,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		})
+		response = append(response, newUserResponse(user, viewer))
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -64,6 +116,12 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id := c.Param("id")
 
+	viewer, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
 	var user models.Users
 	result := h.db.First(&user, id)
 	if result.Error != nil {
@@ -71,81 +129,291 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	response := CreateUserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		FullName:  user.Fullname,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
+	// ผู้ใช้ที่บล็อกกันมองไม่เห็นอีกฝ่าย
+	blocked, err := isBlocked(h.db, viewer.ID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+	if blocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if notModified(c, userETag(user.ID, user.Version)) {
+		return
+	}
+
+	response := newUserResponse(user, viewer)
 
 	c.JSON(http.StatusOK, response)
 
 }
 
+// PATCH /users/:id รับได้ทั้ง JSON Merge Patch และ JSON Patch
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id := c.Param("id")
 
-	var req CreateUserUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var user models.Users
+	result := h.db.First(&user, id)
+	if result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	hashPassword, err := utils.HashPassword(req.HashedPassword)
+	actor, err := currentUser(c, h.db)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+	if actor.ID != user.ID && !actor.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot edit another user"})
+		return
+	}
+
+	if !checkIfMatch(c, userETag(user.ID, user.Version)) {
+		return
+	}
+
+	current := UserPatch{
+		FullName: user.Fullname,
+		Bio:      user.Bio,
+		Location: user.Location,
+		Website:  user.Website,
+		Private:  user.Private,
+	}
+
+	var patched UserPatch
+	changed, ok := bindPatch(c, current, &patched)
+	if !ok {
+		return
+	}
+
+	// Prepare the update data ชื่อ field ใน JSON ตรงกับชื่อคอลัมน์
+	values := map[string]interface{}{
+		"fullName": patched.FullName,
+		"bio":      patched.Bio,
+		"location": patched.Location,
+		"website":  patched.Website,
+		"private":  patched.Private,
+	}
+	updatesUser := map[string]interface{}{}
+	for _, field := range changed {
+		updatesUser[field] = values[field]
+	}
+
+	// การเปิดบัญชีเป็น public จะอนุมัติคำขอติดตามทั้งหมด จึงให้เจ้าของบัญชีทำเองเท่านั้น
+	wasPrivate := user.Private
+	if wasPrivate && !patched.Private && actor.ID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can make the account public"})
+		return
+	}
+
+	if len(updatesUser) > 0 {
+		// Update the user's information
+		// ถ้าเปลี่ยนเป็นบัญชี public คำขอติดตามที่ค้างอยู่จะถูกอนุมัติทั้งหมด
+		before, after := audit.Changes(map[string]interface{}{
+			"fullName": user.Fullname,
+			"bio":      user.Bio,
+			"location": user.Location,
+			"website":  user.Website,
+			"private":  user.Private,
+		}, updatesUser)
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := updateVersioned(tx, &user, user.Version, updatesUser); err != nil {
+				return err
+			}
+			err := audit.Record(tx, auditActor(c, actor), audit.Entry{
+				Action:     audit.ActionUserUpdate,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				Before:     before,
+				After:      after,
+			})
+			if err != nil {
+				return err
+			}
+			if wasPrivate && !patched.Private {
+				return acceptPendingFollows(tx, user.ID, time.Now())
+			}
+			return nil
+		})
+		if err != nil {
+			respondSaveError(c, err)
+			return
+		}
+	}
+
+	// ตอบด้วยค่าที่บันทึกอยู่จริงในฐานข้อมูล
+	result = h.db.First(&user, user.ID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
+	c.Header("ETag", userETag(user.ID, user.Version))
+	c.JSON(http.StatusOK, newUserResponse(user, actor))
+}
+
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
 	var user models.Users
 	result := h.db.First(&user, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "record is not found"})
+		return
+	}
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	// Prepare the update data
-	updatesUser := map[string]interface{}{
-		"hashedPassword": hashPassword,
-		"fullname":       req.FullName,
+	actor, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+	if actor.ID != user.ID && !actor.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot delete another user"})
+		return
 	}
 
-	// Update the user's information
-	result = h.db.Model(&user).Updates(updatesUser)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if !checkIfMatch(c, userETag(user.ID, user.Version)) {
 		return
 	}
 
-	response := CreateUserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		FullName:  user.Fullname,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
+	// ย้ายลงถังขยะพร้อมโพสต์ จะถูกลบถาวรเมื่อพ้นระยะเวลาเก็บ
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := trashUser(tx, user, time.Now()); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, actor), audit.Entry{
+			Action:     audit.ActionUserDelete,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Before:     map[string]interface{}{"username": user.Username, "email": user.Email},
+		})
+	})
+	if err != nil {
+		respondSaveError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{"Success": "removed record"})
+}
+
+// PUT /users/me/password เปลี่ยนรหัสผ่านแล้วยกเลิก session อื่นทั้งหมด
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot update record"})
-	} else {
-		c.JSON(http.StatusOK, response)
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	if !utils.ComparePasswords(user.HashedPassword, req.CurrentPassword) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "current password is incorrect"})
+		return
 	}
+
+	if req.NewPassword == req.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new password must differ from the current password"})
+		return
+	}
+
+	if err := h.policy.Validate(req.NewPassword, user.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var revoked int64
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&user).Update("hashedPassword", hashPassword)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("cannot update record")
+		}
+
+		revoked, err = revokeOtherSessions(tx, user.ID, c.GetString("sid"))
+		if err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, user), audit.Entry{
+			Action:     audit.ActionPasswordChange,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			After:      map[string]interface{}{"revokedSessions": revoked},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Success": "password changed", "revokedSessions": revoked})
 }
 
-func (h *UserHandler) DeleteUser(c *gin.Context) {
-	id := c.Param("id")
+// PUT /users/:id/role เฉพาะ admin เปลี่ยน role ของตัวเองไม่ได้ เพื่อไม่ให้ระบบไม่เหลือ admin
+func (h *UserHandler) ChangeRole(c *gin.Context) {
+	admin, ok := requireAdmin(c, h.db)
+	if !ok {
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	var user models.Users
-	result := h.db.Delete(&user, id)
+	result := h.db.First(&user, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
+	if user.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own role"})
+		return
+	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "record is not found"})
-	} else {
-		c.JSON(http.StatusNoContent, gin.H{"Success": "removed record"})
+	if user.Role != req.Role {
+		previous := user.Role
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := updateVersioned(tx, &user, user.Version, map[string]interface{}{"role": req.Role}); err != nil {
+				return err
+			}
+			return audit.Record(tx, auditActor(c, admin), audit.Entry{
+				Action:     audit.ActionRoleChange,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				Before:     map[string]interface{}{"role": previous},
+				After:      map[string]interface{}{"role": req.Role},
+			})
+		})
+		if err != nil {
+			respondSaveError(c, err)
+			return
+		}
 	}
+
+	c.JSON(http.StatusOK, gin.H{"id": user.ID, "username": user.Username, "role": req.Role})
 }
@@ -21,11 +21,6 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func teardownTestDB(db *gorm.DB) {
-	db.Migrator().DropTable(&models.Users{})
-	db.Exec("DELETE FROM products")
-}
-
 func TestListUsers(t *testing.T) {
 	// เตรียมฐานข้อมูล MySQL ในการเชื่อมต่อกับฐานข้อมูลที่ใช้ในการทดสอบ
 	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
@@ -33,11 +28,12 @@ func TestListUsers(t *testing.T) {
 	assert.NoError(t, err)
 	teardownTestDB(db)
 	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Users{})
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{})
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
-	userHandler := handlers.NewUserHandler(db)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
 
 	clocks := clock.NewMock()
 	clocks.Set(time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local))
@@ -65,6 +61,7 @@ func TestListUsers(t *testing.T) {
 	// เตรียม HTTP request สำหรับการเรียกใช้งาน ListUsers
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user1.Username)
 	c.Request, _ = http.NewRequest("GET", "/users", nil)
 
 	// เรียกใช้งาน ListUsers ผ่าน UserHandler
@@ -88,7 +85,8 @@ func TestListUsers(t *testing.T) {
 
 	assert.Equal(t, user2.Username, response[1].Username)
 	assert.Equal(t, user2.Fullname, response[1].FullName)
-	assert.Equal(t, user2.Email, response[1].Email)
+	// อีเมลของผู้ใช้อื่นไม่ถูกแสดง
+	assert.Equal(t, "", response[1].Email)
 	assert.Equal(t, user2.CreatedAt, response[1].CreatedAt)
 }
 func TestGetUser(t *testing.T) {
@@ -98,11 +96,12 @@ func TestGetUser(t *testing.T) {
 	assert.NoError(t, err)
 	teardownTestDB(db)
 	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Users{})
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{})
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
-	userHandler := handlers.NewUserHandler(db)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
 
 	clocks := clock.NewMock()
 	clocks.Set(time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local))
@@ -121,6 +120,8 @@ func TestGetUser(t *testing.T) {
 	// เตรียม HTTP request สำหรับการเรียกใช้งาน GetUser
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(user.ID), 10)})
 	c.Request, _ = http.NewRequest("GET", "/users/1", nil)
 
 	// เรียกใช้งาน GetUser ผ่าน UserHandler
@@ -139,6 +140,26 @@ func TestGetUser(t *testing.T) {
 	assert.Equal(t, user.Fullname, response.FullName)
 	assert.Equal(t, user.Email, response.Email)
 	assert.Equal(t, user.CreatedAt, response.CreatedAt)
+
+	// ผู้ใช้อื่นไม่เห็นอีเมล ผู้ดูแลระบบเห็น
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	admin := models.Users{Username: "admin_user", Fullname: "Admin User", Email: "admin@example.com", Role: models.RoleAdmin}
+	for _, u := range []*models.Users{&other, &admin} {
+		assert.NoError(t, db.Create(u).Error)
+	}
+	for viewer, email := range map[string]string{other.Username: "", admin.Username: user.Email} {
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Set("username", viewer)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(user.ID), 10)})
+		c.Request, _ = http.NewRequest("GET", "/users/1", nil)
+		userHandler.GetUser(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		response = handlers.CreateUserResponse{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, email, response.Email, viewer)
+	}
 }
 
 func TestCreateUser(t *testing.T) {
@@ -148,11 +169,12 @@ func TestCreateUser(t *testing.T) {
 	assert.NoError(t, err)
 	teardownTestDB(db)
 	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Users{})
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{})
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
-	userHandler := handlers.NewUserHandler(db)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
 
 	// สร้างเครื่องมือทดสอบ HTTP และเรียกใช้งานฟังก์ชัน CreateUser
 	w := httptest.NewRecorder()
@@ -200,11 +222,12 @@ func TestUpdateUser(t *testing.T) {
 	assert.NoError(t, err)
 	teardownTestDB(db)
 	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Users{})
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{})
 	assert.NoError(t, err)
 
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
-	userHandler := handlers.NewUserHandler(db)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
 
 	password, err := utils.HashPassword("password123")
 	assert.NoError(t, err)
@@ -221,7 +244,9 @@ func TestUpdateUser(t *testing.T) {
 	// เตรียม HTTP request สำหรับการเรียกใช้งาน UpdateUser
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Request, _ = http.NewRequest("PUT", "/users/1", bytes.NewReader([]byte(`{"hashedPassword": "test123","fullName": "John Smith"}`)))
+	c.Set("username", user.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(user.ID), 10)})
+	c.Request, _ = http.NewRequest("PATCH", "/users/1", bytes.NewReader([]byte(`{"fullName": "John Smith"}`)))
 	// // เรียกใช้งาน UpdateUser ผ่าน UserHandler
 	userHandler.UpdateUser(c)
 
@@ -235,10 +260,165 @@ func TestUpdateUser(t *testing.T) {
 	// ตรวจสอบค่าข้อมูลผู้ใช้หลังการอัปเดตว่าถูกต้องหรือไม่
 	assert.Equal(t, user.ID, response.ID)
 	assert.Equal(t, user.Username, response.Username)
-	assert.Equal(t, user.Fullname, response.FullName)
+	assert.Equal(t, "John Smith", response.FullName)
 	assert.Equal(t, user.Email, response.Email)
 }
 
+func TestUpdateUserRejectsPassword(t *testing.T) {
+	// เตรียมฐานข้อมูล MySQL ในการเชื่อมต่อกับฐานข้อมูลที่ใช้ในการทดสอบ
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDB(db)
+	// Run migrations สำหรับสร้างตาราง Users
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{})
+	assert.NoError(t, err)
+
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+
+	password, err := utils.HashPassword("password123")
+	assert.NoError(t, err)
+	user := models.Users{
+		Username:       "john_doe",
+		HashedPassword: password,
+		Fullname:       "John Doe",
+		Email:          "john@example.com",
+	}
+	err = db.Create(&user).Error
+	assert.NoError(t, err)
+
+	// แก้รหัสผ่านผ่านการแก้ไขโปรไฟล์ไม่ได้
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(user.ID), 10)})
+	c.Request, _ = http.NewRequest("PATCH", "/users/1", bytes.NewReader([]byte(`{"hashedPassword": "test1234","bio": "Gopher","website": "https://example.com"}`)))
+	userHandler.UpdateUser(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// ข้อมูลเดิมต้องไม่เปลี่ยน
+	var updated models.Users
+	err = db.First(&updated, user.ID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, password, updated.HashedPassword)
+	assert.Equal(t, "", updated.Bio)
+}
+
+func TestUpdateUserOnlyOwner(t *testing.T) {
+	// เตรียมฐานข้อมูล MySQL ในการเชื่อมต่อกับฐานข้อมูลที่ใช้ในการทดสอบ
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDB(db)
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{})
+	assert.NoError(t, err)
+
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+
+	user := models.Users{Username: "john_doe", Fullname: "John Doe", Email: "john@example.com"}
+	other := models.Users{Username: "jane_doe", Fullname: "Jane Doe", Email: "jane@example.com"}
+	admin := models.Users{Username: "admin_user", Fullname: "Admin User", Email: "admin@example.com", Role: models.RoleAdmin}
+	for _, u := range []*models.Users{&user, &other, &admin} {
+		assert.NoError(t, db.Create(u).Error)
+	}
+
+	patch := func(actor models.Users, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", actor.Username)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(user.ID), 10)})
+		c.Request, _ = http.NewRequest("PATCH", "/users/1", bytes.NewReader([]byte(body)))
+		userHandler.UpdateUser(c)
+		return w
+	}
+
+	// ผู้ใช้อื่นแก้ไขโปรไฟล์ไม่ได้
+	w := patch(other, `{"fullName": "Hacked Name"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var updated models.Users
+	err = db.First(&updated, user.ID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", updated.Fullname)
+
+	// ผู้ดูแลระบบแก้ไขได้
+	w = patch(admin, `{"fullName": "John Smith"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	err = db.First(&updated, user.ID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "John Smith", updated.Fullname)
+}
+
+func TestChangePassword(t *testing.T) {
+	// เตรียมฐานข้อมูล MySQL ในการเชื่อมต่อกับฐานข้อมูลที่ใช้ในการทดสอบ
+	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	teardownTestDB(db)
+	db.Migrator().DropTable(&models.Sessions{})
+	err = db.AutoMigrate(&models.Users{}, &models.AuditLogs{}, &models.Sessions{})
+	assert.NoError(t, err)
+
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+
+	password, err := utils.HashPassword("password123")
+	assert.NoError(t, err)
+	user := models.Users{
+		Username:       "john_doe",
+		HashedPassword: password,
+		Fullname:       "John Doe",
+		Email:          "john@example.com",
+	}
+	err = db.Create(&user).Error
+	assert.NoError(t, err)
+
+	// session ที่ใช้อยู่ และ session จากเครื่องอื่น
+	expiresAt := time.Now().Add(time.Hour)
+	current := models.Sessions{ID: "current-session", UserID: user.ID, ExpiresAt: expiresAt}
+	other := models.Sessions{ID: "other-session", UserID: user.ID, ExpiresAt: expiresAt}
+	err = db.Create(&[]models.Sessions{current, other}).Error
+	assert.NoError(t, err)
+
+	changePassword := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", user.Username)
+		c.Set("sid", current.ID)
+		c.Request, _ = http.NewRequest("PUT", "/users/me/password", bytes.NewReader([]byte(body)))
+		userHandler.ChangePassword(c)
+		return w
+	}
+
+	// รหัสผ่านเดิมผิด
+	w := changePassword(`{"currentPassword": "wrong-password","newPassword": "n3w-Secret-pass"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// รหัสผ่านใหม่ไม่ผ่านเงื่อนไข
+	w = changePassword(`{"currentPassword": "password123","newPassword": "john_doe"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = changePassword(`{"currentPassword": "password123","newPassword": "n3w-Secret-pass"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.Users
+	err = db.First(&updated, user.ID).Error
+	assert.NoError(t, err)
+	assert.True(t, utils.ComparePasswords(updated.HashedPassword, "n3w-Secret-pass"))
+
+	// session อื่นถูกยกเลิก แต่ session ที่ใช้อยู่ยังใช้ได้
+	var sessions []models.Sessions
+	err = db.Order("id").Find(&sessions).Error
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Nil(t, sessions[0].RevokedAt)
+	assert.NotNil(t, sessions[1].RevokedAt)
+}
+
 func TestDeleteUser(t *testing.T) {
 	// เตรียมฐานข้อมูล MySQL ในหน่วยทดสอบ
 	dsn := "root:password@tcp(0.0.0.0:3307)/social?charset=utf8mb4&parseTime=True&loc=Local"
@@ -248,8 +428,9 @@ func TestDeleteUser(t *testing.T) {
 	teardownTestDB(db)
 	assert.NoError(t, err)
 
-	// Run migrations สำหรับสร้างตาราง Users
-	err = db.AutoMigrate(&models.Users{})
+	teardownTestDBs(db)
+	// Run migrations สำหรับสร้างตาราง Users และตารางที่ถูกลบตามผู้ใช้
+	err = db.AutoMigrate(append(testTables, &models.Users{}, &models.Follows{}, &models.Sessions{})...)
 	assert.NoError(t, err)
 
 	// เตรียมข้อมูลผู้ใช้ในฐานข้อมูลทดสอบ
@@ -262,12 +443,20 @@ func TestDeleteUser(t *testing.T) {
 	err = db.Create(&user).Error
 	assert.NoError(t, err)
 
+	post := models.Posts{Title: "title123", Body: "body123", UserID: user.ID, Status: "published"}
+	err = db.Create(&post).Error
+	assert.NoError(t, err)
+	err = db.Create(&models.Follows{FollowingUserID: user.ID, FollowerUserID: user.ID + 1}).Error
+	assert.NoError(t, err)
+
 	// สร้าง UserHandler โดยใช้ฐานข้อมูลที่เตรียมไว้
-	userHandler := handlers.NewUserHandler(db)
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
 
 	// สร้างเครื่องมือทดสอบ HTTP และเรียกใช้งานฟังก์ชัน DeleteUser
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Set("username", user.Username)
 
 	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(user.ID), 10)})
 	userHandler.DeleteUser(c)
@@ -279,4 +468,26 @@ func TestDeleteUser(t *testing.T) {
 	var deletedUser models.Users
 	err = db.First(&deletedUser, user.ID).Error
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	// โพสต์ถูกย้ายลงถังขยะพร้อมผู้ใช้ และการติดตามถูกลบ
+	var trashedPost models.Posts
+	err = db.Unscoped().First(&trashedPost, post.PostID).Error
+	assert.NoError(t, err)
+	err = db.Unscoped().First(&deletedUser, user.ID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, deletedUser.DeletedAt.Time, trashedPost.DeletedAt.Time)
+
+	var follows int64
+	err = db.Model(&models.Follows{}).Count(&follows).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), follows)
+}
+
+func TestNewUserHandlerFailsWithoutPolicy(t *testing.T) {
+	// ไฟล์รหัสผ่านที่หลุดเปิดไม่ได้ ต้องไม่สร้าง handler ที่ไม่ตรวจรหัสผ่าน
+	t.Setenv("PASSWORD_BREACHED_FILE", t.TempDir()+"/missing.txt")
+
+	userHandler, err := handlers.NewUserHandler(nil)
+	assert.Error(t, err)
+	assert.Nil(t, userHandler)
 }
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/NopparootSuree/go-social/webhooks"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler struct {
+	db *gorm.DB
+}
+
+func NewWebhookHandler(db *gorm.DB) *WebhookHandler {
+	return &WebhookHandler{
+		db: db,
+	}
+}
+
+// จัดการ req ของการสร้างและแก้ไข webhook ถ้าไม่ใส่ secret ตอนสร้างจะสุ่มให้
+// ตอนแก้ไขใส่ secret เพื่อเปลี่ยนใหม่ ไม่ใส่คือใช้ค่าเดิม
+type WebhookRequest struct {
+	URL        string   `json:"url" binding:"required,http_url,max=1024"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=128"`
+	Active     *bool    `json:"active"`
+}
+
+// secret แสดงเฉพาะตอนสร้างเท่านั้น
+type WebhookResponse struct {
+	ID         uint      `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Active     bool      `json:"active"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedBy  uint      `json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint       `json:"id"`
+	SubscriptionID uint       `json:"subscriptionID"`
+	EventType      string     `json:"eventType"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	LastStatusCode int        `json:"lastStatusCode"`
+	LastError      string     `json:"lastError"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+func newWebhookResponse(subscription models.WebhookSubscriptions) WebhookResponse {
+	return WebhookResponse{
+		ID:         subscription.ID,
+		URL:        subscription.URL,
+		EventTypes: subscription.Types(),
+		Active:     subscription.Active,
+		CreatedBy:  subscription.CreatedBy,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func newWebhookDeliveryResponse(delivery models.WebhookDeliveries) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+// หา webhook จาก :id ถ้าไม่เจอจะตอบ error และคืน false
+func (h *WebhookHandler) subscription(c *gin.Context) (models.WebhookSubscriptions, bool) {
+	var subscription models.WebhookSubscriptions
+	result := h.db.First(&subscription, c.Param("id"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return subscription, false
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return subscription, false
+	}
+	return subscription, true
+}
+
+// field ของ webhook ที่บันทึกใน audit log ไม่รวม secret
+func webhookAuditFields(subscription models.WebhookSubscriptions) map[string]interface{} {
+	return map[string]interface{}{
+		"url":        subscription.URL,
+		"eventTypes": subscription.EventTypes,
+		"active":     subscription.Active,
+	}
+}
+
+// ตรวจชนิด event และตัดตัวซ้ำ
+func webhookEventTypes(types []string) (string, error) {
+	var unique []string
+	seen := map[string]bool{}
+	for _, eventType := range types {
+		if !webhooks.Supported(eventType) {
+			return "", errors.New("unknown event type " + eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	return strings.Join(unique, ","), nil
+}
+
+// POST /webhooks
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	user, ok := requireAdmin(c, h.db)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	eventTypes, err := webhookEventTypes(req.EventTypes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = utils.GenerateToken(32); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	subscription := models.WebhookSubscriptions{
+		URL:        req.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     req.Active == nil || *req.Active,
+		CreatedBy:  user.ID,
+	}
+	// ใช้ Select เพื่อให้บันทึก active เป็น false ได้ ไม่ถูกแทนด้วยค่า default
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("*").Omit("id").Create(&subscription).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, user), audit.Entry{
+			Action:     audit.ActionWebhookCreate,
+			TargetType: audit.TargetWebhook,
+			TargetID:   subscription.ID,
+			After:      webhookAuditFields(subscription),
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := newWebhookResponse(subscription)
+	response.Secret = subscription.Secret
+	c.JSON(http.StatusCreated, response)
+}
+
+// GET /webhooks
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.db); !ok {
+		return
+	}
+
+	var subscriptions []models.WebhookSubscriptions
+	if err := h.db.Order("id").Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []WebhookResponse{}
+	for _, subscription := range subscriptions {
+		response = append(response, newWebhookResponse(subscription))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /webhooks/:id
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.db); !ok {
+		return
+	}
+	subscription, ok := h.subscription(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+// PUT /webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	admin, ok := requireAdmin(c, h.db)
+	if !ok {
+		return
+	}
+	subscription, ok := h.subscription(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	eventTypes, err := webhookEventTypes(req.EventTypes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := webhookAuditFields(subscription)
+	subscription.URL = req.URL
+	subscription.EventTypes = eventTypes
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	// ไม่เก็บ secret ใน audit log บันทึกแค่ว่ามีการเปลี่ยน
+	updates := webhookAuditFields(subscription)
+	if req.Secret != "" {
+		updates["secretRotated"] = true
+	}
+	before, after := audit.Changes(previous, updates)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&subscription).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, admin), audit.Entry{
+			Action:     audit.ActionWebhookUpdate,
+			TargetType: audit.TargetWebhook,
+			TargetID:   subscription.ID,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+// DELETE /webhooks/:id ลบพร้อมประวัติการส่งทั้งหมด
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	admin, ok := requireAdmin(c, h.db)
+	if !ok {
+		return
+	}
+	subscription, ok := h.subscription(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscriptionID = ?", subscription.ID).Delete(&models.WebhookDeliveries{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&subscription).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, admin), audit.Entry{
+			Action:     audit.ActionWebhookDelete,
+			TargetType: audit.TargetWebhook,
+			TargetID:   subscription.ID,
+			Before:     webhookAuditFields(subscription),
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, gin.H{"Success": "deleted webhook"})
+}
+
+// GET /webhooks/:id/deliveries?status= ประวัติการส่ง ล่าสุดก่อน
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.db); !ok {
+		return
+	}
+	subscription, ok := h.subscription(c)
+	if !ok {
+		return
+	}
+
+	query := h.db.Model(&models.WebhookDeliveries{}).Where("subscriptionID = ?", subscription.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query = query.Session(&gorm.Session{})
+
+	pagination := parsePagination(c)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var deliveries []models.WebhookDeliveries
+	err := query.Order("id DESC").Offset(pagination.Offset()).Limit(pagination.PageSize).Find(&deliveries).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := []WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		items = append(items, newWebhookDeliveryResponse(delivery))
+	}
+	c.JSON(http.StatusOK, pagination.Response(items, total))
+}
+
+// POST /webhooks/:id/deliveries/:deliveryID/redeliver สร้างรายการใหม่ด้วย payload เดิมให้ส่งทันที
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	admin, ok := requireAdmin(c, h.db)
+	if !ok {
+		return
+	}
+	subscription, ok := h.subscription(c)
+	if !ok {
+		return
+	}
+
+	var original models.WebhookDeliveries
+	result := h.db.Where("subscriptionID = ?", subscription.ID).First(&original, c.Param("deliveryID"))
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		return
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	delivery := models.WebhookDeliveries{
+		SubscriptionID: subscription.ID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditActor(c, admin), audit.Entry{
+			Action:     audit.ActionWebhookResend,
+			TargetType: audit.TargetWebhook,
+			TargetID:   subscription.ID,
+			After:      map[string]interface{}{"deliveryID": original.ID, "newDeliveryID": delivery.ID},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, newWebhookDeliveryResponse(delivery))
+}
@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/scheduler"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/NopparootSuree/go-social/webhooks"
+	"github.com/benbjohnson/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDelivery(t *testing.T) {
+	db, admin, _ := setupTestData(t)
+	assert.NoError(t, db.Model(&admin).Update("role", models.RoleAdmin).Error)
+	alice := models.Users{Username: "alice", Fullname: "alice", Email: "alice@example.com"}
+	assert.NoError(t, db.Create(&alice).Error)
+	defer events.Subscribe(webhooks.Enqueue)()
+
+	// ปลายทางจำลอง ตอบตาม status ที่ตั้งไว้และเก็บ request ล่าสุด
+	var mu sync.Mutex
+	status := http.StatusInternalServerError
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhookHandler := handlers.NewWebhookHandler(db)
+	request := func(username, method, path, body string, params gin.Params, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("username", username)
+		c.Params = params
+		c.Request, _ = http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		handle(c)
+		return w
+	}
+
+	secret := "0123456789abcdef0123"
+	body := `{"url": "` + server.URL + `", "eventTypes": ["user.registered", "follow.created"], "secret": "` + secret + `"}`
+	w := request(alice.Username, "POST", "/webhooks", body, nil, webhookHandler.CreateWebhook)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = request(admin.Username, "POST", "/webhooks", `{"url": "`+server.URL+`", "eventTypes": ["user.deleted"]}`, nil, webhookHandler.CreateWebhook)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(admin.Username, "POST", "/webhooks", body, nil, webhookHandler.CreateWebhook)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var subscription handlers.WebhookResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &subscription))
+	assert.Equal(t, secret, subscription.Secret)
+	assert.True(t, subscription.Active)
+	webhookID := strconv.FormatUint(uint64(subscription.ID), 10)
+
+	// สมัครสมาชิกแล้วมีรายการรอส่ง
+	userHandler, err := handlers.NewUserHandler(db)
+	assert.NoError(t, err)
+	register, _ := json.Marshal(handlers.CreateUserRequest{
+		Username:       "bobby_b",
+		HashedPassword: "correct-horse-battery",
+		FullName:       "Bobby Brown",
+		Email:          "bobby@example.com",
+	})
+	w = request("", "POST", "/register", string(register), nil, userHandler.Register)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var delivery models.WebhookDeliveries
+	assert.NoError(t, db.Where("subscriptionID = ?", subscription.ID).First(&delivery).Error)
+	assert.Equal(t, events.UserRegistered, delivery.EventType)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+
+	// ส่งไม่สำเร็จสองครั้งจนครบจำนวนครั้ง รอบแรกรอ retryBase ก่อนส่งซ้ำ
+	mock := clock.NewMock()
+	mock.Set(time.Now().Add(time.Second))
+	dispatcher := scheduler.NewWebhookDispatcher(db, time.Minute, time.Second, time.Minute, 2).WithClock(mock)
+
+	n, err := dispatcher.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	db.First(&delivery, delivery.ID)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+
+	n, _ = dispatcher.DeliverDue(context.Background())
+	assert.Equal(t, 0, n)
+	db.First(&delivery, delivery.ID)
+	assert.Equal(t, 1, delivery.Attempts)
+
+	mock.Add(2 * time.Minute)
+	dispatcher.DeliverDue(context.Background())
+	db.First(&delivery, delivery.ID)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+
+	// ส่งซ้ำเองได้ และปลายทางตรวจลายเซ็นได้
+	mu.Lock()
+	status = http.StatusOK
+	mu.Unlock()
+	params := gin.Params{{Key: "id", Value: webhookID}, {Key: "deliveryID", Value: strconv.FormatUint(uint64(delivery.ID), 10)}}
+	w = request(admin.Username, "POST", "/webhooks/"+webhookID+"/deliveries/1/redeliver", "", params, webhookHandler.Redeliver)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	n, err = dispatcher.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	mu.Lock()
+	assert.Equal(t, events.UserRegistered, received.Header.Get("X-Webhook-Event"))
+	timestamp, _ := strconv.ParseInt(received.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	assert.Equal(t, utils.SignWebhook(secret, timestamp, receivedBody), received.Header.Get("X-Webhook-Signature"))
+	assert.Equal(t, delivery.Payload, string(receivedBody))
+	mu.Unlock()
+
+	var list struct {
+		Items []handlers.WebhookDeliveryResponse `json:"items"`
+		Total int64                              `json:"total"`
+	}
+	w = request(admin.Username, "GET", "/webhooks/"+webhookID+"/deliveries", "", gin.Params{{Key: "id", Value: webhookID}}, webhookHandler.ListDeliveries)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, int64(2), list.Total)
+	assert.Equal(t, models.WebhookDeliverySucceeded, list.Items[0].Status)
+	assert.Equal(t, models.WebhookDeliveryFailed, list.Items[1].Status)
+}
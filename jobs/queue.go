@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"gorm.io/gorm"
+)
+
+// ค่าเริ่มต้นของ job
+const (
+	DefaultQueue       = "default"
+	DefaultMaxAttempts = 5
+)
+
+// Options ตัวเลือกตอนสร้าง job ค่าว่างใช้ค่าเริ่มต้น
+// RunAt ใช้ตั้งเวลาให้ทำในอนาคต
+type Options struct {
+	Queue       string
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+// สร้าง job ใหม่ payload ถูกแปลงเป็น JSON แล้วส่งให้ handler ของ jobType
+// ส่ง tx เข้ามาเพื่อให้ job ถูกสร้างเฉพาะเมื่อ transaction ของงานหลัก commit สำเร็จ
+func Enqueue(tx *gorm.DB, jobType string, payload interface{}, opts Options) (models.Jobs, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.Jobs{}, err
+	}
+
+	job := models.Jobs{
+		Queue:       opts.Queue,
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.JobStatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if job.Queue == "" {
+		job.Queue = DefaultQueue
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	err = tx.Create(&job).Error
+	return job, err
+}
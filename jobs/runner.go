@@ -0,0 +1,390 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ระยะห่างสูงสุดระหว่างการทำซ้ำ
+const maxBackoff = 6 * time.Hour
+
+type handlerFunc func(ctx context.Context, payload []byte) error
+
+type cronEntry struct {
+	name     string
+	spec     string
+	schedule Schedule
+	jobType  string
+	payload  interface{}
+	opts     Options
+}
+
+// Runner ดึง job จากฐานข้อมูลมาทำตาม handler ที่ลงทะเบียนไว้ และสร้าง job ตามตาราง cron
+// job ถูกจองด้วย update แบบมีเงื่อนไข ทำให้รันหลาย instance ได้โดยไม่ทำซ้ำ
+type Runner struct {
+	db           *gorm.DB
+	clock        clock.Clock
+	workerID     string
+	poll         time.Duration
+	lease        time.Duration
+	retryBase    time.Duration
+	drainTimeout time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]handlerFunc
+	queues   map[string]int
+	crons    []cronEntry
+}
+
+// lease คือเวลาที่ job ทำได้นานที่สุด ถ้าเกินจะถูกยกเลิกและทำใหม่
+func NewRunner(db *gorm.DB, poll, lease, retryBase, drainTimeout time.Duration) *Runner {
+	hostname, _ := os.Hostname()
+	token, _ := utils.GenerateToken(4)
+	return &Runner{
+		db:           db,
+		clock:        clock.New(),
+		workerID:     fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), token),
+		poll:         poll,
+		lease:        lease,
+		retryBase:    retryBase,
+		drainTimeout: drainTimeout,
+		handlers:     map[string]handlerFunc{},
+		queues:       map[string]int{},
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (r *Runner) WithClock(c clock.Clock) *Runner {
+	r.clock = c
+	return r
+}
+
+// กำหนดจำนวน job ของ queue นี้ที่ทำพร้อมกันได้ใน instance นี้ queue ที่ไม่ได้กำหนดจะไม่ถูกดึงมาทำ
+func (r *Runner) Queue(name string, concurrency int) *Runner {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queues[name] = concurrency
+	return r
+}
+
+// ลงทะเบียน handler ของ jobType payload ถูกแปลงจาก JSON เป็น T ก่อนส่งให้ handler
+// handler ต้องทำซ้ำได้โดยไม่มีผลเพิ่ม เพราะ job ที่ instance ตายระหว่างทำจะถูกทำใหม่
+func Handle[T any](r *Runner, jobType string, handler func(ctx context.Context, payload T) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[jobType] = func(ctx context.Context, data []byte) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		return handler(ctx, payload)
+	}
+}
+
+// สร้าง job ตามตาราง cron ชื่อใช้แยกตารางและต้องไม่ซ้ำกัน
+func (r *Runner) Cron(name, spec, jobType string, payload interface{}, opts Options) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.crons = append(r.crons, cronEntry{name: name, spec: spec, schedule: schedule, jobType: jobType, payload: payload, opts: opts})
+	return nil
+}
+
+// ระยะรอก่อนทำใหม่หลังทำไม่สำเร็จไปแล้ว attempts ครั้ง
+func (r *Runner) backoff(attempts int) time.Duration {
+	wait := r.retryBase
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
+
+// จอง job ที่ถึงเวลาหนึ่งงานจาก queue คืน false ถ้าไม่มีงาน
+func (r *Runner) claim(ctx context.Context, queue string) (models.Jobs, bool, error) {
+	for {
+		now := r.clock.Now()
+		var candidates []models.Jobs
+		err := r.db.WithContext(ctx).
+			Where("queue = ? AND status = ? AND run_at <= ?", queue, models.JobStatusPending, now).
+			Order("run_at, id").
+			Limit(10).
+			Find(&candidates).Error
+		if err != nil || len(candidates) == 0 {
+			return models.Jobs{}, false, err
+		}
+
+		for _, job := range candidates {
+			lockedUntil := now.Add(r.lease)
+			result := r.db.WithContext(ctx).Model(&models.Jobs{}).
+				Where("id = ? AND status = ?", job.ID, models.JobStatusPending).
+				Updates(map[string]interface{}{
+					"status":       models.JobStatusRunning,
+					"attempts":     job.Attempts + 1,
+					"locked_by":    r.workerID,
+					"locked_until": lockedUntil,
+				})
+			if result.Error != nil {
+				return models.Jobs{}, false, result.Error
+			}
+			if result.RowsAffected == 1 {
+				job.Status = models.JobStatusRunning
+				job.Attempts++
+				job.LockedBy = r.workerID
+				job.LockedUntil = &lockedUntil
+				return job, true, nil
+			}
+		}
+		// instance อื่นจองไปหมดแล้ว ลองชุดถัดไป
+	}
+}
+
+// ทำ job หนึ่งงานแล้วบันทึกผล panic ใน handler ถือว่าทำไม่สำเร็จ
+func (r *Runner) run(ctx context.Context, job models.Jobs) error {
+	r.mu.RLock()
+	handler, ok := r.handlers[job.Type]
+	r.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = errors.New("no handler for job type " + job.Type)
+	} else {
+		jobCtx, cancel := context.WithTimeout(ctx, r.lease)
+		err = func() (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+				}
+			}()
+			return handler(jobCtx, []byte(job.Payload))
+		}()
+		cancel()
+	}
+
+	updates := map[string]interface{}{"locked_by": "", "locked_until": nil}
+	now := r.clock.Now()
+	switch {
+	case err == nil:
+		updates["status"] = models.JobStatusSucceeded
+		updates["completed_at"] = now
+		updates["last_error"] = ""
+	case !ok || job.Attempts >= job.MaxAttempts:
+		updates["status"] = models.JobStatusDead
+		updates["completed_at"] = now
+	default:
+		updates["status"] = models.JobStatusPending
+		updates["run_at"] = now.Add(r.backoff(job.Attempts))
+	}
+	if err != nil {
+		message := err.Error()
+		if len(message) > 1024 {
+			message = message[:1024]
+		}
+		updates["last_error"] = message
+		log.Printf("jobs: %s %d attempt %d/%d: %v", job.Type, job.ID, job.Attempts, job.MaxAttempts, err)
+	}
+
+	// บันทึกผลเฉพาะถ้ายังเป็นผู้จองอยู่ ถ้าเลยเวลาจองจนถูกคืนไปแล้วผลนี้จะถูกทิ้ง
+	// ใช้ context ใหม่เพื่อให้บันทึกผลได้แม้ถูกสั่งหยุดระหว่างทำ
+	return r.db.Model(&models.Jobs{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.JobStatusRunning, r.workerID).
+		Updates(updates).Error
+}
+
+// ทำ job ที่ถึงเวลาใน queue จนหมด คืนจำนวนงานที่ทำ ใช้ตอนทดสอบหรือสั่งทำทันที
+func (r *Runner) Work(ctx context.Context, queue string) (int, error) {
+	done := 0
+	for {
+		job, ok, err := r.claim(ctx, queue)
+		if err != nil || !ok {
+			return done, err
+		}
+		if err := r.run(ctx, job); err != nil {
+			return done, err
+		}
+		done++
+	}
+}
+
+// คืน job ที่เลยเวลาจองแล้วให้กลับไปรอทำใหม่ เช่น instance ที่ทำอยู่ตายไป
+// ถ้าทำครบจำนวนครั้งแล้วจะย้ายไป dead
+func (r *Runner) RecoverExpired(ctx context.Context) (int64, error) {
+	now := r.clock.Now()
+	expired := r.db.WithContext(ctx).Model(&models.Jobs{}).
+		Where("status = ? AND locked_until < ?", models.JobStatusRunning, now)
+
+	dead := expired.Session(&gorm.Session{}).Where("attempts >= max_attempts").Updates(map[string]interface{}{
+		"status":       models.JobStatusDead,
+		"locked_by":    "",
+		"locked_until": nil,
+		"completed_at": now,
+		"last_error":   "lease expired",
+	})
+	if dead.Error != nil {
+		return 0, dead.Error
+	}
+
+	retry := expired.Session(&gorm.Session{}).Updates(map[string]interface{}{
+		"status":       models.JobStatusPending,
+		"locked_by":    "",
+		"locked_until": nil,
+		"run_at":       now,
+		"last_error":   "lease expired",
+	})
+	return dead.RowsAffected + retry.RowsAffected, retry.Error
+}
+
+// สร้าง job ของตาราง cron ที่ถึงเวลา คืนจำนวน job ที่สร้าง
+// ตารางถูกเลื่อนด้วย update แบบมีเงื่อนไข ถ้าหลาย instance ทำพร้อมกันจะมีเพียงตัวเดียวที่สร้าง job
+func (r *Runner) EnqueueDue(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	crons := r.crons
+	r.mu.RUnlock()
+
+	created := 0
+	now := r.clock.Now()
+	for _, entry := range crons {
+		row := models.JobSchedules{Name: entry.name, Spec: entry.spec, NextRunAt: entry.schedule.Next(now)}
+		err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error
+		if err != nil {
+			return created, err
+		}
+		if err := r.db.WithContext(ctx).First(&row, "name = ?", entry.name).Error; err != nil {
+			return created, err
+		}
+
+		// เปลี่ยนตารางแล้วคำนวณเวลาใหม่ ไม่สร้าง job ตามตารางเดิม
+		if row.Spec != entry.spec {
+			err := r.db.WithContext(ctx).Model(&row).Where("spec = ?", row.Spec).Updates(map[string]interface{}{
+				"spec":        entry.spec,
+				"next_run_at": entry.schedule.Next(now),
+			}).Error
+			if err != nil {
+				return created, err
+			}
+			continue
+		}
+		if row.NextRunAt.After(now) {
+			continue
+		}
+
+		claimed := false
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.JobSchedules{}).
+				Where("name = ? AND next_run_at = ?", row.Name, row.NextRunAt).
+				Update("next_run_at", entry.schedule.Next(now))
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			claimed = true
+			opts := entry.opts
+			opts.RunAt = now
+			_, err := Enqueue(tx, entry.jobType, entry.payload, opts)
+			return err
+		})
+		if err != nil {
+			return created, err
+		}
+		if claimed {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// รันจนกว่า ctx จะถูก cancel แล้วรอ job ที่กำลังทำอยู่ให้เสร็จ
+// ถ้าเกิน drainTimeout job ที่ยังไม่เสร็จจะถูกยกเลิกและกลับไปรอทำใหม่ตาม backoff
+func (r *Runner) Start(ctx context.Context) {
+	// context ของ job แยกจาก ctx เพื่อให้ job ที่กำลังทำได้ทำต่อระหว่างรอปิด
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	r.mu.RLock()
+	for queue, concurrency := range r.queues {
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(queue string) {
+				defer wg.Done()
+				r.work(ctx, jobCtx, queue)
+			}(queue)
+		}
+	}
+	r.mu.RUnlock()
+
+	ticker := r.clock.Ticker(r.poll)
+	defer ticker.Stop()
+	for {
+		if n, err := r.EnqueueDue(ctx); err != nil {
+			log.Printf("jobs: enqueue cron jobs: %v", err)
+		} else if n > 0 {
+			log.Printf("jobs: enqueued %d cron jobs", n)
+		}
+		if n, err := r.RecoverExpired(ctx); err != nil {
+			log.Printf("jobs: recover expired jobs: %v", err)
+		} else if n > 0 {
+			log.Printf("jobs: recovered %d expired jobs", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			r.drain(&wg, cancelJobs)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) drain(wg *sync.WaitGroup, cancelJobs context.CancelFunc) {
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-r.clock.After(r.drainTimeout):
+		log.Printf("jobs: drain timeout, cancelling running jobs")
+		cancelJobs()
+		<-drained
+	}
+}
+
+// worker หนึ่งตัวของ queue ดึงงานทีละงานจนกว่า ctx จะถูก cancel
+func (r *Runner) work(ctx, jobCtx context.Context, queue string) {
+	for ctx.Err() == nil {
+		job, ok, err := r.claim(ctx, queue)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("jobs: claim from %s: %v", queue, err)
+		}
+		if !ok {
+			select {
+			case <-ctx.Done():
+			case <-r.clock.After(r.poll):
+			}
+			continue
+		}
+		if err := r.run(jobCtx, job); err != nil {
+			log.Printf("jobs: record %s %d: %v", job.Type, job.ID, err)
+		}
+	}
+}
@@ -1,10 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/jobs"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/NopparootSuree/go-social/outbox"
+	"github.com/NopparootSuree/go-social/realtime"
 	"github.com/NopparootSuree/go-social/routers"
+	"github.com/NopparootSuree/go-social/scheduler"
+	"github.com/NopparootSuree/go-social/search"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/NopparootSuree/go-social/trending"
 	"github.com/NopparootSuree/go-social/utils"
+	"github.com/NopparootSuree/go-social/webhooks"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -21,16 +38,149 @@ func init() {
 
 func main() {
 	r := gin.Default()
+	// เชื่อ X-Forwarded-For เฉพาะจาก proxy ใน TRUSTED_PROXIES ค่าเริ่มต้นไม่เชื่อใคร
+	// เพื่อให้ IP ใน audit log ปลอมไม่ได้
+	if err := r.SetTrustedProxies(utils.ListEnv("TRUSTED_PROXIES")); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	db, err := utils.ConnectDatabase()
 	if err != nil {
 		panic("Failed connect to Database")
 	}
 
-	routers.UserRouter(r, db)
+	// ctx ถูก cancel เมื่อได้รับสัญญาณให้ปิด เพื่อให้งาน background หยุดและ job ที่ทำอยู่เสร็จก่อน
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// ตัว publish โพสต์ที่ตั้งเวลาไว้
+	publisher := scheduler.NewPostPublisher(db, utils.DurationEnv("POST_PUBLISH_INTERVAL", 30*time.Second))
+	go publisher.Start(ctx)
+
+	// ตัวค้นหา ถ้าใช้ index ในหน่วยความจำต้องสร้าง index ใหม่เป็นระยะ
+	engine, err := search.New(db)
+	if err != nil {
+		log.Fatalf("Failed to set up search: %v", err)
+	}
+	if memory, ok := engine.(*search.MemoryEngine); ok {
+		go memory.Run(ctx, utils.DurationEnv("SEARCH_REFRESH_INTERVAL", time.Minute))
+	}
+
+	// คำนวณ trending ใน background และเก็บผลไว้เป็น cache
+	aggregator := trending.NewAggregator(db, utils.DurationEnv("TRENDING_REFRESH_INTERVAL", time.Minute))
+	go aggregator.Run(ctx)
+
+	// ที่เก็บไฟล์แนบ
+	store, err := storage.New()
+	if err != nil {
+		log.Fatalf("Failed to set up storage: %v", err)
+	}
+
+	// job queue งานที่ใช้เวลานานหรือทำตามตาราง cron
+	runner := jobs.NewRunner(db,
+		utils.DurationEnv("JOB_POLL_INTERVAL", time.Second),
+		utils.DurationEnv("JOB_LEASE", 5*time.Minute),
+		utils.DurationEnv("JOB_RETRY_BASE", 30*time.Second),
+		utils.DurationEnv("JOB_DRAIN_TIMEOUT", 30*time.Second))
+	runner.Queue(jobs.DefaultQueue, utils.IntEnv("JOB_CONCURRENCY", 4))
+	runner.Queue("maintenance", 1)
+
+	// ลบโพสต์และผู้ใช้ในถังขยะที่พ้นระยะเวลาเก็บแล้ว ทำตามตาราง cron ผ่าน job queue
+	purger := scheduler.NewTrashPurger(db, store, utils.TrashRetention())
+	jobs.Handle(runner, "trash.purge", func(ctx context.Context, _ struct{}) error {
+		users, posts, err := purger.PurgeExpired()
+		if users > 0 || posts > 0 {
+			log.Printf("jobs: purged %d users and %d posts from trash", users, posts)
+		}
+		return err
+	})
+	if err := runner.Cron("trash.purge", utils.StringEnv("TRASH_PURGE_CRON", "@hourly"), "trash.purge", struct{}{}, jobs.Options{Queue: "maintenance"}); err != nil {
+		log.Fatalf("Failed to schedule trash purge: %v", err)
+	}
+	drained := make(chan struct{})
+	go func() {
+		runner.Start(ctx)
+		close(drained)
+	}()
+
+	// สร้างไฟล์ส่งออกข้อมูลส่วนตัว และลบบัญชีที่พ้น grace period แล้ว
+	exporter := scheduler.NewDataExporter(db, store, utils.DurationEnv("DATA_EXPORT_INTERVAL", 10*time.Second), utils.DurationEnv("DATA_EXPORT_TTL", 7*24*time.Hour))
+	go exporter.Start(ctx)
+	eraser := scheduler.NewAccountEraser(db, store, utils.DurationEnv("ACCOUNT_ERASURE_INTERVAL", time.Hour))
+	go eraser.Start(ctx)
+
+	// ส่ง feed การแจ้งเตือน และจำนวน reaction ให้ client ที่เชื่อมต่อ stream อยู่
+	broker, err := realtime.NewBroker()
+	if err != nil {
+		log.Fatalf("Failed to set up stream broker: %v", err)
+	}
+	hub := realtime.NewHub(broker, utils.IntEnv("STREAM_HISTORY_SIZE", 1000))
+	go hub.Run(ctx)
+
+	// ส่ง event ให้ระบบภายนอกผ่าน webhook ส่งซ้ำเมื่อไม่สำเร็จ
+	events.Subscribe(webhooks.Enqueue)
+	dispatcher := scheduler.NewWebhookDispatcher(db,
+		utils.DurationEnv("WEBHOOK_DELIVERY_INTERVAL", 5*time.Second),
+		utils.DurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		utils.DurationEnv("WEBHOOK_RETRY_BASE", 30*time.Second),
+		utils.IntEnv("WEBHOOK_MAX_ATTEMPTS", 8))
+	go dispatcher.Start(ctx)
+
+	// domain event ถูกบันทึกลง outbox พร้อมข้อมูล แล้วส่งออกไปยัง sink ใน background
+	sink, err := outbox.NewSink()
+	if err != nil {
+		log.Fatalf("Failed to set up outbox sink: %v", err)
+	}
+	events.Subscribe(outbox.Record)
+	// stream รับ event จาก relay หลัง commit แล้วเท่านั้น
+	if bus, ok := sink.(*outbox.Bus); ok {
+		bus.Handle("*", hub.Handler(db))
+	} else {
+		log.Printf("Stream receives no events because OUTBOX_SINK is not bus")
+	}
+	relay := outbox.NewRelay(db, sink, utils.DurationEnv("OUTBOX_RELAY_INTERVAL", time.Second), utils.DurationEnv("OUTBOX_RETENTION", 7*24*time.Hour))
+	go relay.Start(ctx)
+
+	// ใส่ request id ให้ทุก request ก่อนลงทะเบียน route เพื่อให้ใช้ใน audit log ได้
+	r.Use(middlewares.RequestID())
+
+	if err := routers.UserRouter(r, db); err != nil {
+		log.Fatalf("Failed to set up user routes: %v", err)
+	}
+	routers.ProfileRouter(r, db, store)
+	routers.PrivacyRouter(r, db, store)
+	routers.FollowRouter(r, db)
+	routers.BlockRouter(r, db)
+	routers.NotificationRouter(r, db)
 	routers.PostRouter(r, db)
-	routers.AuthenRouter(r, db)
+	routers.CommentRouter(r, db)
+	routers.ReactionRouter(r, db)
+	routers.TagRouter(r, db)
+	routers.AttachmentRouter(r, db, store)
+	if err := routers.AuthenRouter(r, db); err != nil {
+		log.Fatalf("Failed to set up authentication routes: %v", err)
+	}
+	routers.SearchRouter(r, db, engine)
+	routers.TrendingRouter(r, db, aggregator)
+	routers.StreamRouter(r, db, hub)
+	routers.WebhookRouter(r, db)
+	routers.JobRouter(r, db)
+	routers.AuditRouter(r, db)
 
 	r.Use(cors.Default())
-	r.Run(":8080")
+	server := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// หยุดรับ request ใหม่ แล้วรอ request และ job ที่ทำอยู่ให้เสร็จก่อนปิด
+	<-ctx.Done()
+	shutdown, cancel := context.WithTimeout(context.Background(), utils.DurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second))
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+	<-drained
 }
@@ -39,6 +39,10 @@ func JWTMiddleware(secretKey []byte) gin.HandlerFunc {
 			c.Header("Authorization", authHeader)
 			//set username ใน claims
 			c.Set("username", claims["username"])
+			//set session id ใน claims ไว้ให้ SessionMiddleware ตรวจ
+			if sid, ok := claims["sid"].(string); ok {
+				c.Set("sid", sid)
+			}
 			c.Next()
 		} else {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -47,3 +51,16 @@ func JWTMiddleware(secretKey []byte) gin.HandlerFunc {
 		}
 	}
 }
+
+// เหมือน JWTMiddleware แต่ไม่บังคับ login ถ้าไม่มี Authorization header จะผ่านไปแบบไม่ระบุตัวตน
+// ถ้าส่ง token มาแต่ไม่ถูกต้องยังตอบ 401 เพื่อไม่ให้ client เข้าใจผิดว่า login อยู่
+func OptionalJWTMiddleware(secretKey []byte) gin.HandlerFunc {
+	required := JWTMiddleware(secretKey)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
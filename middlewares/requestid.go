@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"regexp"
+
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// header ที่ใช้ส่ง request id ไปกลับ
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// ใช้ request id จาก header ถ้ารูปแบบถูกต้อง ไม่เช่นนั้นสุ่มใหม่ แล้ว set "requestID" ใน context และ header ของ response
+// ใช้โยง log และ audit log กับ request ของ client หรือ proxy
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			token, err := utils.GenerateToken(16)
+			if err != nil {
+				token = utils.GenerateRandomString(32)
+			}
+			id = token
+		}
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
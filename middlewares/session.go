@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ใช้ต่อจาก JWTMiddleware ตรวจว่า session ของ token ยังไม่ถูกยกเลิกหรือหมดอายุ
+// request ที่ผ่าน OptionalJWTMiddleware มาโดยไม่มี token จะไม่ถูกตรวจ
+func SessionMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, authenticated := c.Get("username"); !authenticated {
+			c.Next()
+			return
+		}
+
+		sid := c.GetString("sid")
+		if sid == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		var count int64
+		err := db.Model(&models.Sessions{}).
+			Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sid, time.Now()).
+			Count(&count).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,29 +1,391 @@
 package models
 
 import (
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// สถานะของโพสต์
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
+)
+
+// ผู้ที่มองเห็นโพสต์ได้
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityMentioned = "mentioned"
+	VisibilityPrivate   = "private"
+)
+
+// สถานะที่โพสต์เปลี่ยนไปได้ จาก -> ไป
+var postTransitions = map[string][]string{
+	PostStatusDraft:     {PostStatusScheduled, PostStatusPublished, PostStatusArchived},
+	PostStatusScheduled: {PostStatusDraft, PostStatusPublished, PostStatusArchived},
+	PostStatusPublished: {PostStatusArchived},
+	PostStatusArchived:  {PostStatusDraft, PostStatusPublished},
+}
+
+// สิทธิ์ของผู้ใช้
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
 )
 
 type Users struct {
-	ID             uint      `gorm:"primarykey;column:id;autoIncrement"`
-	Username       string    `gorm:"column:username;not null"`
-	HashedPassword string    `gorm:"column:hashedPassword;not null"`
-	Fullname       string    `gorm:"column:fullName;not null"`
-	Email          string    `gorm:"column:email;index;not null"`
-	CreatedAt      time.Time `gorm:"column:created_at"`
+	ID             uint           `gorm:"primarykey;column:id;autoIncrement"`
+	Username       string         `gorm:"column:username;not null"`
+	HashedPassword string         `gorm:"column:hashedPassword;not null"`
+	Fullname       string         `gorm:"column:fullName;not null"`
+	Email          string         `gorm:"column:email;index;not null"`
+	Bio            string         `gorm:"column:bio;type:text"`
+	Location       string         `gorm:"column:location;size:100"`
+	Website        string         `gorm:"column:website;size:255"`
+	AvatarKey      string         `gorm:"column:avatarKey;size:255"`
+	AvatarURL      string         `gorm:"column:avatarURL;size:1024"`
+	BannerKey      string         `gorm:"column:bannerKey;size:255"`
+	BannerURL      string         `gorm:"column:bannerURL;size:1024"`
+	Role           string         `gorm:"column:role;size:20;not null;default:user"`
+	Private        bool           `gorm:"column:private;not null;default:false"`
+	LockedAt       *time.Time     `gorm:"column:locked_at"`
+	Version        uint           `gorm:"column:version;not null;default:1"`
+	CreatedAt      time.Time      `gorm:"column:created_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (u Users) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// บัญชีที่ถูกล็อกโดยผู้ดูแลระบบ login ไม่ได้จนกว่าจะถูกปลดล็อก
+func (u Users) IsLocked() bool {
+	return u.LockedAt != nil
+}
+
+// session ของการ login แต่ละครั้ง ใช้ยกเลิก token ที่ออกไปแล้ว
+type Sessions struct {
+	ID        string     `gorm:"primarykey;column:id;size:64"`
+	UserID    uint       `gorm:"column:userID;index;not null"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
 }
 
 type Posts struct {
-	PostID    uint      `gorm:"primarykey;column:postID;autoIncrement"`
+	PostID     uint           `gorm:"primarykey;column:postID;autoIncrement"`
+	Title      string         `gorm:"column:title;not null"`
+	Body       string         `gorm:"column:body;not null"`
+	UserID     uint           `gorm:"column:userID;index;foreignkey:UserID;references:ID;not null"`
+	Status     string         `gorm:"column:status;index;not null"`
+	PublishAt  *time.Time     `gorm:"column:publish_at;index"`
+	Visibility string         `gorm:"column:visibility;size:20;index;not null;default:public"`
+	Version    uint           `gorm:"column:version;not null;default:1"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+// เก็บค่าเดิมของโพสต์ก่อนถูกแก้ไขแต่ละครั้ง
+type PostRevisions struct {
+	ID        uint      `gorm:"primarykey;column:id;autoIncrement"`
+	PostID    uint      `gorm:"column:postID;uniqueIndex:idx_post_revision;not null"`
+	Revision  uint      `gorm:"column:revision;uniqueIndex:idx_post_revision;not null"`
+	EditorID  uint      `gorm:"column:editorID;index;not null"`
 	Title     string    `gorm:"column:title;not null"`
 	Body      string    `gorm:"column:body;not null"`
-	UserID    uint      `gorm:"column:userID;index;foreignkey:UserID;references:ID;not null"`
 	Status    string    `gorm:"column:status;not null"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 }
 
+// ความคิดเห็นของโพสต์ ParentID ใช้ตอบกลับความคิดเห็นอื่นเป็น thread
+// DeletedAt ไม่ใช้ gorm.DeletedAt เพื่อให้ยังดึงแถวที่ถูกลบมาแสดงโครงสร้าง thread ได้
+type Comments struct {
+	ID        uint       `gorm:"primarykey;column:id;autoIncrement"`
+	PostID    uint       `gorm:"column:postID;index;foreignkey:PostID;references:PostID;not null"`
+	UserID    uint       `gorm:"column:userID;index;foreignkey:UserID;references:ID;not null"`
+	ParentID  *uint      `gorm:"column:parentID;index"`
+	Body      string     `gorm:"column:body;type:text;not null"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at"`
+	DeletedAt *time.Time `gorm:"column:deleted_at"`
+}
+
+// reaction ของผู้ใช้ต่อโพสต์ ผู้ใช้หนึ่งคนกด reaction แต่ละแบบได้ครั้งเดียวต่อโพสต์
+type Reactions struct {
+	ID        uint      `gorm:"primarykey;column:id;autoIncrement"`
+	PostID    uint      `gorm:"column:postID;uniqueIndex:idx_reaction;index;foreignkey:PostID;references:PostID;not null"`
+	UserID    uint      `gorm:"column:userID;uniqueIndex:idx_reaction;index;foreignkey:UserID;references:ID;not null"`
+	Type      string    `gorm:"column:type;size:32;uniqueIndex:idx_reaction;not null"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// ไฟล์แนบของโพสต์ เก็บ key ใน storage และ URL ที่ใช้แสดงผล
+type Attachments struct {
+	ID           uint      `gorm:"primarykey;column:id;autoIncrement"`
+	PostID       uint      `gorm:"column:postID;index;foreignkey:PostID;references:PostID;not null"`
+	UserID       uint      `gorm:"column:userID;index;not null"`
+	Key          string    `gorm:"column:key;size:255;not null"`
+	ThumbnailKey string    `gorm:"column:thumbnailKey;size:255;not null"`
+	URL          string    `gorm:"column:url;size:1024;not null"`
+	ThumbnailURL string    `gorm:"column:thumbnailURL;size:1024;not null"`
+	ContentType  string    `gorm:"column:contentType;size:64;not null"`
+	Size         int64     `gorm:"column:size;not null"`
+	Width        int       `gorm:"column:width;not null"`
+	Height       int       `gorm:"column:height;not null"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+}
+
+// hashtag ที่ normalize เป็นตัวพิมพ์เล็กแล้ว
+type Tags struct {
+	ID        uint      `gorm:"primarykey;column:id;autoIncrement"`
+	Name      string    `gorm:"column:name;size:100;uniqueIndex;not null"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+type PostTags struct {
+	PostID    uint      `gorm:"primarykey;column:postID;autoIncrement:false"`
+	TagID     uint      `gorm:"primarykey;column:tagID;index;autoIncrement:false"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// ผู้ใช้ที่ถูก @mention ในโพสต์ NotifiedAt ใช้กันไม่ให้แจ้งเตือนซ้ำ
+type Mentions struct {
+	PostID     uint       `gorm:"primarykey;column:postID;autoIncrement:false"`
+	UserID     uint       `gorm:"primarykey;column:userID;index;autoIncrement:false"`
+	NotifiedAt *time.Time `gorm:"column:notified_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+}
+
+// ชนิดของการแจ้งเตือน
+const (
+	NotificationMention        = "mention"
+	NotificationFollow         = "follow"
+	NotificationFollowRequest  = "follow_request"
+	NotificationFollowAccepted = "follow_accepted"
+	NotificationComment        = "comment"
+	NotificationReply          = "reply"
+	NotificationReaction       = "reaction"
+)
+
+// ชนิดการแจ้งเตือนทั้งหมดที่ผู้ใช้ตั้งค่าเปิดปิดได้
+var NotificationTypes = []string{
+	NotificationMention,
+	NotificationFollow,
+	NotificationFollowRequest,
+	NotificationFollowAccepted,
+	NotificationComment,
+	NotificationReply,
+	NotificationReaction,
+}
+
+type Notifications struct {
+	ID        uint       `gorm:"primarykey;column:id;autoIncrement"`
+	UserID    uint       `gorm:"column:userID;index;not null"`
+	ActorID   uint       `gorm:"column:actorID;not null"`
+	Type      string     `gorm:"column:type;size:32;not null"`
+	PostID    *uint      `gorm:"column:postID"`
+	CommentID *uint      `gorm:"column:commentID"`
+	ReadAt    *time.Time `gorm:"column:read_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;index"`
+}
+
+// การตั้งค่าแจ้งเตือนรายชนิด ถ้าไม่มีแถวถือว่าเปิดอยู่
+type NotificationPreferences struct {
+	UserID  uint   `gorm:"primarykey;column:userID;autoIncrement:false"`
+	Type    string `gorm:"primarykey;column:type;size:32"`
+	Enabled bool   `gorm:"column:enabled;not null"`
+}
+
+// สถานะการติดตาม บัญชี private ต้องอนุมัติคำขอก่อน
+const (
+	FollowStatusPending  = "pending"
+	FollowStatusAccepted = "accepted"
+)
+
 type Follows struct {
-	FollowingUserID uint      `gorm:"column:followingUserID;foreignkey:FollowingUserID;references:ID;not null"`
-	FollowerUserID  uint      `gorm:"column:followerUserID;foreignkey:FollowerUserID;references:ID;not null"`
-	CreatedAt       time.Time `gorm:"column:created_at"`
+	FollowingUserID uint       `gorm:"column:followingUserID;foreignkey:FollowingUserID;references:ID;not null"`
+	FollowerUserID  uint       `gorm:"column:followerUserID;foreignkey:FollowerUserID;references:ID;not null"`
+	Status          string     `gorm:"column:status;size:20;index;not null;default:accepted"`
+	AcceptedAt      *time.Time `gorm:"column:accepted_at"`
+	CreatedAt       time.Time  `gorm:"column:created_at"`
+}
+
+// ผู้ใช้ที่ถูกบล็อกมองไม่เห็นและโต้ตอบกับผู้บล็อกไม่ได้ทั้งสองทาง
+type Blocks struct {
+	BlockerUserID uint      `gorm:"primarykey;column:blockerUserID;autoIncrement:false"`
+	BlockedUserID uint      `gorm:"primarykey;column:blockedUserID;autoIncrement:false;index"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+}
+
+// โพสต์ของผู้ใช้ที่ถูก mute จะไม่แสดงใน feed ของผู้ mute
+type Mutes struct {
+	MuterUserID uint      `gorm:"primarykey;column:muterUserID;autoIncrement:false"`
+	MutedUserID uint      `gorm:"primarykey;column:mutedUserID;autoIncrement:false"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+// สถานะของไฟล์ส่งออกข้อมูลส่วนตัว
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+)
+
+// คำขอส่งออกข้อมูลส่วนตัวของผู้ใช้ ไฟล์ zip ถูกสร้างใน background แล้วเก็บไว้ใน storage
+type DataExports struct {
+	ID          uint       `gorm:"primarykey;column:id;autoIncrement"`
+	UserID      uint       `gorm:"column:userID;index;not null"`
+	Status      string     `gorm:"column:status;size:20;index;not null"`
+	Key         string     `gorm:"column:key;size:255"`
+	Size        int64      `gorm:"column:size"`
+	Error       string     `gorm:"column:error;size:1024"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at;index"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+}
+
+// คำขอลบบัญชี ต้องยืนยันด้วย token ก่อน แล้วจะถูกลบจริงเมื่อถึง EraseAt
+type AccountErasures struct {
+	UserID         uint       `gorm:"primarykey;column:userID;autoIncrement:false"`
+	TokenHash      string     `gorm:"column:tokenHash;size:64;not null"`
+	TokenExpiresAt time.Time  `gorm:"column:token_expires_at;not null"`
+	ConfirmedAt    *time.Time `gorm:"column:confirmed_at"`
+	EraseAt        *time.Time `gorm:"column:erase_at;index"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+}
+
+// ปลายทางที่รับ event ผ่าน webhook จัดการโดย admin
+// EventTypes เก็บชนิด event ที่รับคั่นด้วย comma
+type WebhookSubscriptions struct {
+	ID         uint      `gorm:"primarykey;column:id;autoIncrement"`
+	URL        string    `gorm:"column:url;size:1024;not null"`
+	EventTypes string    `gorm:"column:event_types;size:512;not null"`
+	Secret     string    `gorm:"column:secret;size:128;not null"`
+	Active     bool      `gorm:"column:active;index;not null;default:true"`
+	CreatedBy  uint      `gorm:"column:createdBy"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+}
+
+// ชนิด event ที่ subscription นี้รับ
+func (s WebhookSubscriptions) Types() []string {
+	if s.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(s.EventTypes, ",")
+}
+
+// subscription นี้รับ event ชนิดนี้หรือไม่
+func (s WebhookSubscriptions) Accepts(eventType string) bool {
+	for _, t := range s.Types() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// สถานะการส่ง webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// การส่ง event หนึ่งครั้งให้ subscription หนึ่ง ถูกส่งซ้ำจนสำเร็จหรือครบจำนวนครั้ง
+type WebhookDeliveries struct {
+	ID             uint       `gorm:"primarykey;column:id;autoIncrement"`
+	SubscriptionID uint       `gorm:"column:subscriptionID;index;not null"`
+	EventType      string     `gorm:"column:event_type;size:64;not null"`
+	Payload        string     `gorm:"column:payload;type:text;not null"`
+	Status         string     `gorm:"column:status;size:20;index:idx_webhook_due,priority:1;not null"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;index:idx_webhook_due,priority:2"`
+	LastStatusCode int        `gorm:"column:last_status_code"`
+	LastError      string     `gorm:"column:last_error;size:1024"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+}
+
+// event ที่รอส่งออกไปยัง sink บันทึกใน transaction เดียวกับการเปลี่ยนแปลงข้อมูล
+// ถูกส่งตามลำดับ ID ภายใน aggregate เดียวกัน PublishedAt ว่างคือยังไม่ได้ส่ง
+type OutboxEvents struct {
+	ID            uint       `gorm:"primarykey;column:id;autoIncrement"`
+	AggregateType string     `gorm:"column:aggregate_type;size:32;not null"`
+	AggregateID   uint       `gorm:"column:aggregateID;not null"`
+	EventType     string     `gorm:"column:event_type;size:64;not null"`
+	Payload       string     `gorm:"column:payload;type:text;not null"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	LastError     string     `gorm:"column:last_error;size:1024"`
+	PublishedAt   *time.Time `gorm:"column:published_at;index"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+}
+
+// สถานะของ job dead คือทำไม่สำเร็จจนครบจำนวนครั้ง รอ admin ตรวจสอบและสั่งทำใหม่
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
+
+// งานที่รอทำใน background แต่ละ queue จำกัดจำนวนงานที่ทำพร้อมกันแยกกัน
+// LockedUntil คือเวลาที่ worker จองงานไว้ ถ้าเลยเวลาแล้วยังไม่เสร็จงานจะกลับไปรอทำใหม่
+type Jobs struct {
+	ID          uint       `gorm:"primarykey;column:id;autoIncrement"`
+	Queue       string     `gorm:"column:queue;size:64;index:idx_job_due,priority:1;not null"`
+	Type        string     `gorm:"column:type;size:64;index;not null"`
+	Payload     string     `gorm:"column:payload;type:text;not null"`
+	Status      string     `gorm:"column:status;size:20;index:idx_job_due,priority:2;not null"`
+	Attempts    int        `gorm:"column:attempts;not null;default:0"`
+	MaxAttempts int        `gorm:"column:max_attempts;not null"`
+	RunAt       time.Time  `gorm:"column:run_at;index:idx_job_due,priority:3"`
+	LockedBy    string     `gorm:"column:locked_by;size:64"`
+	LockedUntil *time.Time `gorm:"column:locked_until;index"`
+	LastError   string     `gorm:"column:last_error;size:1024"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"`
+}
+
+// เวลาที่ cron job จะถูกสร้างครั้งถัดไป ใช้ร่วมกันทุก instance เพื่อไม่ให้สร้างซ้ำ
+type JobSchedules struct {
+	Name      string    `gorm:"primarykey;column:name;size:64"`
+	Spec      string    `gorm:"column:spec;size:64;not null"`
+	NextRunAt time.Time `gorm:"column:next_run_at;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// บันทึกการกระทำที่เกี่ยวกับความปลอดภัยและงานของ admin เพิ่มได้อย่างเดียว ห้ามแก้ไขหรือลบ
+// ActorID เป็น 0 ถ้าไม่รู้ว่าใครทำ เช่น login ด้วยชื่อผู้ใช้ที่ไม่มีอยู่ Before และ After เป็น JSON ของ field ที่เปลี่ยน
+type AuditLogs struct {
+	ID         uint      `gorm:"primarykey;column:id;autoIncrement"`
+	ActorID    uint      `gorm:"column:actorID;index"`
+	ActorName  string    `gorm:"column:actor_name;size:64"`
+	Action     string    `gorm:"column:action;size:64;index;not null"`
+	TargetType string    `gorm:"column:target_type;size:32;index:idx_audit_target,priority:1"`
+	TargetID   uint      `gorm:"column:targetID;index:idx_audit_target,priority:2"`
+	Before     string    `gorm:"column:before;type:text"`
+	After      string    `gorm:"column:after;type:text"`
+	IP         string    `gorm:"column:ip;size:64"`
+	RequestID  string    `gorm:"column:requestID;size:64;index"`
+	CreatedAt  time.Time `gorm:"column:created_at;index"`
+}
+
+// เช็คว่าเปลี่ยนสถานะโพสต์จาก from ไป to ได้หรือไม่
+func CanTransitionPost(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range postTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
 }
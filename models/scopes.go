@@ -0,0 +1,44 @@
+package models
+
+import "gorm.io/gorm"
+
+// โพสต์ที่ viewer มองเห็นได้ viewerID เป็น 0 เมื่อไม่ได้ login
+// เจ้าของเห็นโพสต์ของตัวเองทั้งหมด คนอื่นเห็นเฉพาะโพสต์ที่ published แล้วตาม visibility
+//   - public: ทุกคน ถ้าเจ้าของเป็นบัญชี private จะเห็นเฉพาะผู้ติดตามที่อนุมัติแล้ว
+//   - followers: ผู้ติดตามที่อนุมัติแล้วและผู้ที่ถูก mention
+//   - mentioned: เฉพาะผู้ที่ถูก mention
+//   - private: เฉพาะเจ้าของ
+//
+// โพสต์ของผู้ใช้ที่บล็อกกับ viewer ไม่ว่าทางไหนจะถูกซ่อน
+func VisiblePosts(viewerID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		publicAccount := "NOT EXISTS (SELECT 1 FROM users WHERE users.id = posts.userID AND users.private = ?)"
+		if viewerID == 0 {
+			return db.Where("posts.status = ? AND posts.visibility = ? AND "+publicAccount,
+				PostStatusPublished, VisibilityPublic, true)
+		}
+
+		return db.Scopes(NotBlocked(viewerID, "posts.userID")).Where(
+			"posts.userID = ? OR (posts.status = ? AND ("+
+				"(posts.visibility = ? AND "+publicAccount+") "+
+				"OR (posts.visibility IN ? AND EXISTS (SELECT 1 FROM follows WHERE follows.followingUserID = posts.userID AND follows.followerUserID = ? AND follows.status = ?)) "+
+				"OR (posts.visibility IN ? AND EXISTS (SELECT 1 FROM mentions WHERE mentions.postID = posts.postID AND mentions.userID = ?))))",
+			viewerID, PostStatusPublished,
+			VisibilityPublic, true,
+			[]string{VisibilityPublic, VisibilityFollowers}, viewerID, FollowStatusAccepted,
+			[]string{VisibilityFollowers, VisibilityMentioned}, viewerID,
+		)
+	}
+}
+
+// ตัดแถวที่ผู้ใช้ในคอลัมน์ column บล็อก viewer หรือถูก viewer บล็อก viewerID เป็น 0 จะไม่กรอง
+func NotBlocked(viewerID uint, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if viewerID == 0 {
+			return db
+		}
+		return db.Where("NOT EXISTS (SELECT 1 FROM blocks WHERE "+
+			"(blocks.blockerUserID = ? AND blocks.blockedUserID = "+column+") OR "+
+			"(blocks.blockerUserID = "+column+" AND blocks.blockedUserID = ?))", viewerID, viewerID)
+	}
+}
@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"gorm.io/gorm"
+)
+
+// ชนิดของ aggregate ลำดับของ event รับประกันภายใน aggregate เดียวกัน
+const (
+	AggregateUser = "user"
+	AggregatePost = "post"
+)
+
+// Message event ที่ส่งให้ sink ID คือ id ในตาราง outbox ใช้กันการประมวลผลซ้ำได้
+// เพราะ relay ส่งแบบ at-least-once
+type Message struct {
+	ID            uint            `json:"id"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   uint            `json:"aggregateID"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+func newMessage(row models.OutboxEvents) Message {
+	return Message{
+		ID:            row.ID,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Type:          row.EventType,
+		Payload:       json.RawMessage(row.Payload),
+		CreatedAt:     row.CreatedAt,
+	}
+}
+
+// aggregate ของ event คืน false ถ้าไม่ใช่ event ที่บันทึกลง outbox
+// การแจ้งเตือนอยู่ใน aggregate ของผู้รับ เพื่อให้ส่งถึงผู้รับตามลำดับ
+func aggregate(event events.Event) (string, uint, bool) {
+	if event.NotificationID != 0 {
+		return AggregateUser, event.UserID, true
+	}
+	switch event.Type {
+	case events.UserRegistered, events.FollowCreated:
+		return AggregateUser, event.ActorID, true
+	case events.PostCreated, events.PostPublished, events.PostDeleted, events.ReactionChanged:
+		if event.PostID == nil {
+			return "", 0, false
+		}
+		return AggregatePost, *event.PostID, true
+	}
+	return "", 0, false
+}
+
+// Record ใช้ลงทะเบียนกับ events.Subscribe บันทึก domain event ลง outbox ใน transaction เดียวกับ event
+// ถ้า transaction rollback event ก็หายไปด้วย ถ้า commit แล้ว relay จะส่งออกแม้ process ตายก่อนตอบ request
+func Record(tx *gorm.DB, event events.Event) error {
+	aggregateType, aggregateID, ok := aggregate(event)
+	if !ok {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvents{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     event.Type,
+		Payload:       string(payload),
+		CreatedAt:     event.CreatedAt,
+	}).Error
+}
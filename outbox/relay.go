@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+)
+
+// ชื่อ lock ของ MySQL ที่ให้ relay ทำงานได้ทีละ instance
+const relayLock = "go-social.outbox.relay"
+
+// จำนวน event ที่ส่งต่อรอบ
+const relayBatchSize = 500
+
+// Relay ส่ง event ใน outbox ที่ยังไม่ได้ส่งให้ sink ตามลำดับ id แบบ at-least-once
+// ถ้าส่ง event ใดไม่สำเร็จ event ถัดไปของ aggregate เดียวกันจะรอรอบถัดไป aggregate อื่นยังส่งต่อได้
+type Relay struct {
+	db        *gorm.DB
+	sink      Sink
+	clock     clock.Clock
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewRelay(db *gorm.DB, sink Sink, interval, retention time.Duration) *Relay {
+	return &Relay{
+		db:        db,
+		sink:      sink,
+		clock:     clock.New(),
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (r *Relay) WithClock(c clock.Clock) *Relay {
+	r.clock = c
+	return r
+}
+
+// ส่ง event ที่รออยู่ คืนจำนวนที่ส่งสำเร็จ ถ้า instance อื่นกำลังส่งอยู่จะไม่ทำอะไร
+// lock ผูกกับ connection จึงต้องใช้ connection เดียวตลอดรอบ
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	sent := 0
+	err := r.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var locked int
+		if err := conn.Raw("SELECT COALESCE(GET_LOCK(?, 0), 0)", relayLock).Row().Scan(&locked); err != nil {
+			return err
+		}
+		if locked != 1 {
+			return nil
+		}
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", relayLock)
+
+		var err error
+		sent, err = r.relay(ctx, conn)
+		return err
+	})
+	return sent, err
+}
+
+func (r *Relay) relay(ctx context.Context, conn *gorm.DB) (int, error) {
+	var pending []models.OutboxEvents
+	err := conn.Where("published_at IS NULL").Order("id").Limit(relayBatchSize).Find(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	failed := map[string]bool{}
+	for _, row := range pending {
+		key := fmt.Sprintf("%s:%d", row.AggregateType, row.AggregateID)
+		if failed[key] {
+			continue
+		}
+
+		if err := r.sink.Publish(ctx, newMessage(row)); err != nil {
+			failed[key] = true
+			log.Printf("outbox: publish event %d (%s %s): %v", row.ID, row.EventType, key, err)
+			message := err.Error()
+			if len(message) > 1024 {
+				message = message[:1024]
+			}
+			err = conn.Model(&models.OutboxEvents{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": message,
+			}).Error
+			if err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		err := conn.Model(&models.OutboxEvents{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"published_at": r.clock.Now(),
+		}).Error
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// ลบ event ที่ส่งแล้วและเก่ากว่าระยะเวลาเก็บ คืนจำนวนที่ลบ
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("published_at IS NOT NULL AND published_at <= ?", r.clock.Now().Add(-r.retention)).
+		Delete(&models.OutboxEvents{})
+	return result.RowsAffected, result.Error
+}
+
+// รันจนกว่า ctx จะถูก cancel
+func (r *Relay) Start(ctx context.Context) {
+	ticker := r.clock.Ticker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// ส่งต่อทันทีถ้ายังมีค้างเต็ม batch
+		for {
+			n, err := r.RelayPending(ctx)
+			if err != nil {
+				log.Printf("outbox: relay: %v", err)
+			}
+			if err != nil || n < relayBatchSize {
+				break
+			}
+		}
+		if _, err := r.Cleanup(ctx); err != nil {
+			log.Printf("outbox: cleanup: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
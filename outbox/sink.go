@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Sink ปลายทางของ event จาก outbox ถ้าคืน error relay จะส่ง event นี้ซ้ำในรอบถัดไป
+// และหยุดส่ง event ถัดไปของ aggregate เดียวกันไว้ก่อนเพื่อรักษาลำดับ
+// sink ที่ต่อกับ message broker ต้องคืน nil เมื่อ broker ยืนยันการรับแล้วเท่านั้น
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// สร้าง Sink ตาม env OUTBOX_SINK
+// bus ส่งให้ handler ภายใน process และ file เขียนต่อท้ายไฟล์ OUTBOX_FILE ทีละบรรทัด
+func NewSink() (Sink, error) {
+	switch os.Getenv("OUTBOX_SINK") {
+	case "", "bus":
+		return NewBus(), nil
+	case "file":
+		path := os.Getenv("OUTBOX_FILE")
+		if path == "" {
+			path = "outbox.jsonl"
+		}
+		return NewFileSink(path)
+	default:
+		return nil, errors.New("outbox: unknown OUTBOX_SINK " + os.Getenv("OUTBOX_SINK"))
+	}
+}
+
+// Handler ประมวลผล event จาก Bus ต้องทำซ้ำได้โดยไม่มีผลเพิ่ม เพราะ event อาจถูกส่งมากกว่าหนึ่งครั้ง
+type Handler func(ctx context.Context, msg Message) error
+
+// Bus ส่ง event ให้ handler ภายใน process ตามชนิดของ event
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+// ลงทะเบียน handler ของ event ชนิดนี้ ใช้ "*" เพื่อรับทุกชนิด
+func (b *Bus) Handle(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// เรียก handler ทุกตัว ถ้ามีตัวใดล้มเหลวทั้ง event จะถูกส่งซ้ำ รวมถึง handler ที่สำเร็จไปแล้วด้วย
+func (b *Bus) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[msg.Type]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileSink เขียน event ต่อท้ายไฟล์บรรทัดละหนึ่ง JSON และ sync ลง disk ก่อนยืนยัน
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Publish(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
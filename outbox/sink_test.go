@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Handle("post.created", func(ctx context.Context, msg Message) error {
+		got = append(got, "created:"+msg.Type)
+		return nil
+	})
+	bus.Handle("*", func(ctx context.Context, msg Message) error {
+		got = append(got, "all:"+msg.Type)
+		return nil
+	})
+
+	assert.NoError(t, bus.Publish(context.Background(), Message{ID: 1, Type: "post.created"}))
+	assert.NoError(t, bus.Publish(context.Background(), Message{ID: 2, Type: "post.deleted"}))
+	assert.Equal(t, []string{"created:post.created", "all:post.created", "all:post.deleted"}, got)
+
+	// handler ที่ล้มเหลวทำให้ทั้ง event ล้มเหลว
+	failure := errors.New("boom")
+	bus.Handle("post.deleted", func(ctx context.Context, msg Message) error { return failure })
+	assert.ErrorIs(t, bus.Publish(context.Background(), Message{ID: 3, Type: "post.deleted"}), failure)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	sink, err := NewFileSink(path)
+	assert.NoError(t, err)
+
+	for id := uint(1); id <= 3; id++ {
+		msg := Message{ID: id, AggregateType: AggregatePost, AggregateID: 7, Type: "post.created", Payload: json.RawMessage(`{"postID":7}`)}
+		assert.NoError(t, sink.Publish(context.Background(), msg))
+	}
+	assert.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var ids []uint
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var msg Message
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		assert.JSONEq(t, `{"postID":7}`, string(msg.Payload))
+		ids = append(ids, msg.ID)
+	}
+	assert.Equal(t, []uint{1, 2, 3}, ids)
+}
@@ -0,0 +1,105 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message ข้อความที่ส่งให้ client แบบ real-time
+// UserIDs ว่างหมายถึงส่งให้ทุกคนที่เชื่อมต่ออยู่ ยกเว้นผู้ใช้ใน ExcludedUserIDs
+type Message struct {
+	ID              uint64          `json:"id"`
+	Type            string          `json:"type"`
+	UserIDs         []uint          `json:"-"`
+	ExcludedUserIDs []uint          `json:"-"`
+	Data            json.RawMessage `json:"data"`
+	CreatedAt       time.Time       `json:"createdAt"`
+}
+
+// ข้อความนี้ส่งถึงผู้ใช้คนนี้หรือไม่
+func (m Message) For(userID uint) bool {
+	for _, id := range m.ExcludedUserIDs {
+		if id == userID {
+			return false
+		}
+	}
+	if len(m.UserIDs) == 0 {
+		return true
+	}
+	for _, id := range m.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Broker กระจายข้อความระหว่าง instance ของ server และเป็นผู้กำหนด ID ของข้อความ
+// ID ต้องเพิ่มขึ้นเรื่อยๆ เพื่อให้ client resume ด้วย Last-Event-ID ได้
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+	// channel ถูกปิดเมื่อ ctx ถูก cancel
+	Subscribe(ctx context.Context) (<-chan Message, error)
+}
+
+// สร้าง Broker ตาม env STREAM_BROKER ตอนนี้มีเฉพาะ memory ซึ่งใช้ได้กับ instance เดียว
+func NewBroker() (Broker, error) {
+	switch os.Getenv("STREAM_BROKER") {
+	case "", "memory":
+		return NewMemoryBroker(), nil
+	default:
+		return nil, errors.New("realtime: unknown STREAM_BROKER " + os.Getenv("STREAM_BROKER"))
+	}
+}
+
+// MemoryBroker ส่งข้อความภายใน process เดียว
+type MemoryBroker struct {
+	mu          sync.Mutex
+	seq         uint64
+	subscribers map[chan Message]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: map[chan Message]struct{}{}}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	msg.ID = b.seq
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	// รอจน subscriber รับข้อความ ไม่ทิ้งข้อความเงียบๆ hub อ่านจาก channel ตลอดจึงรอไม่นาน
+	// ส่งภายใต้ lock เพื่อให้ทุก subscriber ได้ข้อความตามลำดับ ID
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context) (<-chan Message, error) {
+	ch := make(chan Message, 256)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		close(ch)
+		b.mu.Unlock()
+	}()
+	return ch, nil
+}
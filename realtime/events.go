@@ -0,0 +1,183 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/outbox"
+	"gorm.io/gorm"
+)
+
+// ชนิดของข้อความที่ส่งให้ client
+const (
+	MessageFeedPost      = "feed.post"
+	MessageNotification  = "notification"
+	MessageReactionCount = "reaction.count"
+)
+
+type notificationData struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	ActorID   uint      `json:"actorID"`
+	PostID    *uint     `json:"postID"`
+	CommentID *uint     `json:"commentID"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type feedPostData struct {
+	PostID     uint       `json:"postID"`
+	UserID     uint       `json:"userID"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	Visibility string     `json:"visibility"`
+	PublishAt  *time.Time `json:"publishAt"`
+}
+
+type reactionCountData struct {
+	PostID uint             `json:"postID"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// Handler ใช้ลงทะเบียนกับ outbox.Bus แปลง event ที่ commit แล้วเป็นข้อความ real-time
+// จึงไม่มีข้อความของ transaction ที่ rollback หลุดออกไป และอ่านข้อมูลล่าสุดจาก db ตอนส่ง
+// ส่งไม่สำเร็จแค่ log ไว้ ไม่ให้ relay ส่ง event ซ้ำจนค้าง event ถัดไป
+func (h *Hub) Handler(db *gorm.DB) outbox.Handler {
+	return func(ctx context.Context, msg outbox.Message) error {
+		var event events.Event
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			log.Printf("realtime: decode event %d: %v", msg.ID, err)
+			return nil
+		}
+
+		tx := db.WithContext(ctx)
+		var err error
+		switch {
+		case event.NotificationID != 0:
+			err = h.Publish(ctx, MessageNotification, []uint{event.UserID}, notificationData{
+				ID:        event.NotificationID,
+				Type:      event.Type,
+				ActorID:   event.ActorID,
+				PostID:    event.PostID,
+				CommentID: event.CommentID,
+				CreatedAt: event.CreatedAt,
+			})
+		case event.Type == events.PostPublished && event.PostID != nil:
+			err = h.publishFeedPost(tx, *event.PostID)
+		case event.Type == events.ReactionChanged && event.PostID != nil:
+			err = h.publishReactionCount(tx, *event.PostID)
+		}
+		if err != nil {
+			log.Printf("realtime: publish %s: %v", event.Type, err)
+		}
+		return nil
+	}
+}
+
+func (h *Hub) publishFeedPost(tx *gorm.DB, postID uint) error {
+	var post models.Posts
+	if err := tx.First(&post, postID).Error; err != nil {
+		return err
+	}
+
+	audience, err := feedAudience(tx, post)
+	if err != nil {
+		return err
+	}
+	return h.Publish(tx.Statement.Context, MessageFeedPost, audience, feedPostData{
+		PostID:     post.PostID,
+		UserID:     post.UserID,
+		Title:      post.Title,
+		Body:       post.Body,
+		Visibility: post.Visibility,
+		PublishAt:  post.PublishAt,
+	})
+}
+
+// จำนวน reaction ของโพสต์ public จากบัญชี public ส่งให้ทุกคนยกเว้นผู้ที่บล็อกกับเจ้าของ
+// โพสต์อื่นส่งเฉพาะผู้ที่มองเห็นโพสต์
+func (h *Hub) publishReactionCount(tx *gorm.DB, postID uint) error {
+	var post models.Posts
+	if err := tx.First(&post, postID).Error; err != nil {
+		return err
+	}
+	if post.Status != models.PostStatusPublished {
+		return nil
+	}
+
+	var author models.Users
+	if err := tx.First(&author, post.UserID).Error; err != nil {
+		return err
+	}
+	broadcast := post.Visibility == models.VisibilityPublic && !author.Private
+	var audience, blocked []uint
+	var err error
+	if broadcast {
+		blocked, err = blockedWith(tx, post.UserID)
+	} else {
+		audience, err = feedAudience(tx, post)
+	}
+	if err != nil {
+		return err
+	}
+
+	var rows []struct {
+		Type  string
+		Total int64
+	}
+	err = tx.Model(&models.Reactions{}).
+		Select("type, COUNT(*) AS total").
+		Where("postID = ?", post.PostID).
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Type] = row.Total
+	}
+
+	data := reactionCountData{PostID: post.PostID, Counts: counts}
+	if broadcast {
+		return h.PublishExcept(tx.Statement.Context, MessageReactionCount, blocked, data)
+	}
+	return h.Publish(tx.Statement.Context, MessageReactionCount, audience, data)
+}
+
+// ผู้ที่ได้เห็นโพสต์ใน feed คือเจ้าของ และผู้ติดตามที่ไม่ได้ mute เจ้าของหรือผู้ที่ถูก mention ตาม visibility
+func feedAudience(tx *gorm.DB, post models.Posts) ([]uint, error) {
+	audience := []uint{post.UserID}
+
+	var others []uint
+	var err error
+	switch post.Visibility {
+	case models.VisibilityPublic, models.VisibilityFollowers:
+		muters := tx.Model(&models.Mutes{}).Select("muterUserID").Where("mutedUserID = ?", post.UserID)
+		err = tx.Model(&models.Follows{}).
+			Where("followingUserID = ? AND status = ?", post.UserID, models.FollowStatusAccepted).
+			Where("followerUserID NOT IN (?)", muters).
+			Pluck("followerUserID", &others).Error
+	case models.VisibilityMentioned:
+		err = tx.Model(&models.Mentions{}).Where("postID = ?", post.PostID).Pluck("userID", &others).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(audience, others...), nil
+}
+
+// ผู้ใช้ที่บล็อกหรือถูกบล็อกโดยผู้ใช้คนนี้
+func blockedWith(tx *gorm.DB, userID uint) ([]uint, error) {
+	var blockers, blocked []uint
+	if err := tx.Model(&models.Blocks{}).Where("blockedUserID = ?", userID).Pluck("blockerUserID", &blockers).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.Blocks{}).Where("blockerUserID = ?", userID).Pluck("blockedUserID", &blocked).Error; err != nil {
+		return nil, err
+	}
+	return append(blockers, blocked...), nil
+}
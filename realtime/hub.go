@@ -0,0 +1,132 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// จำนวนข้อความที่รอส่งได้ต่อ client ถ้าเต็ม client จะถูกตัดการเชื่อมต่อให้ resume ใหม่
+const clientBuffer = 64
+
+// Client การเชื่อมต่อหนึ่งของผู้ใช้ C ถูกปิดเมื่อถูกถอดออกจาก hub
+type Client struct {
+	UserID uint
+	C      <-chan Message
+	ch     chan Message
+}
+
+// Hub รับข้อความจาก Broker แล้วส่งต่อให้ client ที่เชื่อมต่อกับ instance นี้
+// เก็บข้อความล่าสุดไว้จำนวนหนึ่งเพื่อส่งย้อนหลังให้ client ที่ resume ด้วย Last-Event-ID
+type Hub struct {
+	broker      Broker
+	historySize int
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	history []Message
+}
+
+func NewHub(broker Broker, historySize int) *Hub {
+	return &Hub{
+		broker:      broker,
+		historySize: historySize,
+		clients:     map[*Client]struct{}{},
+	}
+}
+
+// ส่งข้อความผ่าน broker ให้ทุก instance
+func (h *Hub) Publish(ctx context.Context, messageType string, userIDs []uint, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return h.broker.Publish(ctx, Message{Type: messageType, UserIDs: userIDs, Data: raw})
+}
+
+// ส่งข้อความผ่าน broker ให้ทุกคนยกเว้นผู้ใช้ใน excluded
+func (h *Hub) PublishExcept(ctx context.Context, messageType string, excluded []uint, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return h.broker.Publish(ctx, Message{Type: messageType, ExcludedUserIDs: excluded, Data: raw})
+}
+
+// รันจนกว่า ctx จะถูก cancel
+func (h *Hub) Run(ctx context.Context) {
+	messages, err := h.broker.Subscribe(ctx)
+	if err != nil {
+		log.Printf("realtime: subscribe broker: %v", err)
+		return
+	}
+
+	for msg := range messages {
+		h.dispatch(msg)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		h.remove(client)
+	}
+}
+
+func (h *Hub) dispatch(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.history = append(h.history, msg)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for client := range h.clients {
+		if !msg.For(client.UserID) {
+			continue
+		}
+		select {
+		case client.ch <- msg:
+		default:
+			log.Printf("realtime: drop slow client of user %d", client.UserID)
+			h.remove(client)
+		}
+	}
+}
+
+// ลงทะเบียน client คืนข้อความหลัง lastEventID ที่ต้องส่งก่อนข้อความจาก C
+// ข้อความย้อนหลังและการลงทะเบียนทำภายใต้ lock เดียวกันจึงไม่มีข้อความหายหรือซ้ำ
+func (h *Hub) Subscribe(userID uint, lastEventID uint64) (*Client, []Message) {
+	ch := make(chan Message, clientBuffer)
+	client := &Client{UserID: userID, C: ch, ch: ch}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var backlog []Message
+	if lastEventID > 0 {
+		for _, msg := range h.history {
+			if msg.ID > lastEventID && msg.For(userID) {
+				backlog = append(backlog, msg)
+			}
+		}
+	}
+	h.clients[client] = struct{}{}
+	return client, backlog
+}
+
+func (h *Hub) Unsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(client)
+}
+
+// ต้องถือ lock อยู่
+func (h *Hub) remove(client *Client) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	close(client.ch)
+}
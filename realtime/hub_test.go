@@ -0,0 +1,120 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(t *testing.T, client *Client) Message {
+	select {
+	case msg := <-client.C:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return Message{}
+	}
+}
+
+func TestHubDeliversToRecipients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := NewHub(NewMemoryBroker(), 10)
+	go hub.Run(ctx)
+	// รอให้ hub subscribe broker ก่อนส่งข้อความ
+	time.Sleep(10 * time.Millisecond)
+
+	alice, _ := hub.Subscribe(1, 0)
+	bob, _ := hub.Subscribe(2, 0)
+
+	assert.NoError(t, hub.Publish(ctx, MessageNotification, []uint{2}, map[string]int{"id": 1}))
+	assert.NoError(t, hub.Publish(ctx, MessageReactionCount, nil, map[string]int{"postID": 1}))
+
+	// ข้อความที่ระบุผู้รับส่งเฉพาะคนนั้น ข้อความที่ไม่ระบุส่งทุกคน
+	msg := receive(t, bob)
+	assert.Equal(t, MessageNotification, msg.Type)
+	assert.JSONEq(t, `{"id":1}`, string(msg.Data))
+	assert.Equal(t, MessageReactionCount, receive(t, bob).Type)
+	assert.Equal(t, MessageReactionCount, receive(t, alice).Type)
+
+	hub.Unsubscribe(alice)
+	_, ok := <-alice.C
+	assert.False(t, ok)
+}
+
+func TestHubResumesFromLastEventID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := NewHub(NewMemoryBroker(), 2)
+	go hub.Run(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	listener, _ := hub.Subscribe(1, 0)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, hub.Publish(ctx, MessageFeedPost, []uint{1}, i))
+	}
+	var last Message
+	for i := 0; i < 3; i++ {
+		last = receive(t, listener)
+	}
+	assert.Equal(t, uint64(3), last.ID)
+
+	// เก็บย้อนหลังแค่ 2 ข้อความ resume จากข้อความแรกจะได้ข้อความที่ 2 และ 3
+	_, backlog := hub.Subscribe(1, 1)
+	assert.Len(t, backlog, 2)
+	assert.Equal(t, uint64(2), backlog[0].ID)
+	assert.Equal(t, uint64(3), backlog[1].ID)
+
+	// ผู้ใช้อื่นไม่ได้รับข้อความย้อนหลังของคนอื่น
+	_, backlog = hub.Subscribe(2, 1)
+	assert.Empty(t, backlog)
+}
+
+func TestHubPublishExcept(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := NewHub(NewMemoryBroker(), 10)
+	go hub.Run(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	alice, _ := hub.Subscribe(1, 0)
+	blocked, _ := hub.Subscribe(2, 0)
+
+	assert.NoError(t, hub.PublishExcept(ctx, MessageReactionCount, []uint{2}, map[string]int{"postID": 1}))
+	assert.NoError(t, hub.Publish(ctx, MessageNotification, []uint{2}, map[string]int{"id": 1}))
+
+	// ผู้ใช้ที่ถูกยกเว้นไม่ได้รับข้อความที่ส่งให้ทุกคน
+	assert.Equal(t, MessageReactionCount, receive(t, alice).Type)
+	assert.Equal(t, MessageNotification, receive(t, blocked).Type)
+}
+
+func TestMemoryBrokerDoesNotDropMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := NewMemoryBroker()
+	messages, err := broker.Subscribe(ctx)
+	assert.NoError(t, err)
+
+	// ส่งเกินขนาด buffer โดยยังไม่มีใครอ่าน ข้อความต้องไม่หาย
+	const total = 300
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < total; i++ {
+			assert.NoError(t, broker.Publish(ctx, Message{Type: MessageFeedPost}))
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	for i := 1; i <= total; i++ {
+		select {
+		case msg := <-messages:
+			assert.Equal(t, uint64(i), msg.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("message %d was not delivered", i)
+		}
+	}
+	<-done
+}
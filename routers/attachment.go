@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AttachmentRouter(router *gin.Engine, db *gorm.DB, store storage.Storage) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	attachmentHandler := handlers.NewAttachmentHandler(db, store)
+	attachments := router.Group("/posts/:id/attachments", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		attachments.POST("", attachmentHandler.UploadAttachment)
+		attachments.DELETE("/:attachmentID", attachmentHandler.DeleteAttachment)
+	}
+
+	// ถ้าเก็บไฟล์ในเครื่อง ให้ gin เสิร์ฟไฟล์เอง ยกเว้นไฟล์ส่วนตัว เช่นไฟล์ส่งออกข้อมูล
+	if local, ok := store.(*storage.Local); ok {
+		files := gin.WrapH(local.Handler())
+		router.GET(local.URLPrefix+"/*filepath", files)
+		router.HEAD(local.URLPrefix+"/*filepath", files)
+	}
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AuditRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	auditHandler := handlers.NewAuditHandler(db)
+	auditLogs := router.Group("/audit-logs", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		auditLogs.GET("", auditHandler.ListAuditLogs)
+		auditLogs.GET("/export", auditHandler.ExportAuditLogs)
+	}
+}
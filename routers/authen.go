@@ -6,11 +6,15 @@ import (
 	"gorm.io/gorm"
 )
 
-func AuthenRouter(router *gin.Engine, db *gorm.DB) {
-	authenHandler := handlers.NewUserHandler(db)
+func AuthenRouter(router *gin.Engine, db *gorm.DB) error {
+	authenHandler, err := handlers.NewUserHandler(db)
+	if err != nil {
+		return err
+	}
 	authen := router.Group("/")
 	{
 		authen.POST("/login", authenHandler.Login)
 		authen.POST("/register", authenHandler.Register)
 	}
+	return nil
 }
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func BlockRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	blockHandler := handlers.NewBlockHandler(db)
+	blocks := router.Group("/users", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		blocks.POST("/:id/block", blockHandler.Block)
+		blocks.DELETE("/:id/block", blockHandler.Unblock)
+		blocks.GET("/me/blocks", blockHandler.ListBlocks)
+		blocks.POST("/:id/mute", blockHandler.Mute)
+		blocks.DELETE("/:id/mute", blockHandler.Unmute)
+		blocks.GET("/me/mutes", blockHandler.ListMutes)
+	}
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func CommentRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	commentHandler := handlers.NewCommentHandler(db)
+	comments := router.Group("/posts/:id/comments", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		comments.GET("", commentHandler.ListComments)
+		comments.POST("", commentHandler.CreateComment)
+		comments.PUT("/:commentID", commentHandler.UpdateComment)
+		comments.DELETE("/:commentID", commentHandler.DeleteComment)
+	}
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func FollowRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	followHandler := handlers.NewFollowHandler(db)
+	follows := router.Group("/users", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		follows.POST("/:id/follow", followHandler.Follow)
+		follows.DELETE("/:id/follow", followHandler.Unfollow)
+		follows.GET("/me/follow-requests", followHandler.ListRequests)
+		follows.POST("/me/follow-requests/:followerID/approve", followHandler.ApproveRequest)
+		follows.POST("/me/follow-requests/:followerID/reject", followHandler.RejectRequest)
+	}
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func JobRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	jobHandler := handlers.NewJobHandler(db)
+	jobs := router.Group("/jobs", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		jobs.GET("", jobHandler.ListJobs)
+		jobs.GET("/stats", jobHandler.Stats)
+		jobs.GET("/:id", jobHandler.GetJob)
+		jobs.POST("/:id/retry", jobHandler.RetryJob)
+		jobs.DELETE("/:id", jobHandler.DeleteJob)
+	}
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NotificationRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	notificationHandler := handlers.NewNotificationHandler(db)
+	notifications := router.Group("/notifications", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		notifications.GET("", notificationHandler.ListNotifications)
+		notifications.POST("/read", notificationHandler.MarkRead)
+		notifications.POST("/read-all", notificationHandler.MarkAllRead)
+		notifications.GET("/preferences", notificationHandler.GetPreferences)
+		notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+	}
+}
@@ -12,13 +12,25 @@ import (
 func PostRouter(router *gin.Engine, db *gorm.DB) {
 	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
 	postHandler := handlers.NewPostHandler(db)
-	posts := router.Group("/posts", middlewares.JWTMiddleware(secretKey))
+	// โพสต์ public อ่านได้โดยไม่ต้อง login
+	public := router.Group("/posts", middlewares.OptionalJWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		public.GET("", postHandler.ListPosts)
+		public.GET("/:id", postHandler.GetPost)
+	}
 
+	posts := router.Group("/posts", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
 	{
-		posts.GET("", postHandler.ListPosts)
-		posts.GET("/:id", postHandler.GetPost)
+		posts.GET("/trash", postHandler.ListTrash)
 		posts.POST("", postHandler.CreatePost)
 		posts.PUT("/:id", postHandler.UpdatePost)
+		posts.PATCH("/:id", postHandler.PatchPost)
 		posts.DELETE("/:id", postHandler.DeletePost)
+		posts.POST("/:id/restore", postHandler.RestorePost)
+		posts.GET("/:id/revisions", postHandler.ListRevisions)
+		posts.GET("/:id/revisions/diff", postHandler.DiffRevisions)
+		posts.POST("/:id/revisions/:rev/restore", postHandler.RestoreRevision)
 	}
+
+	router.GET("/feed", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db), postHandler.Feed)
 }
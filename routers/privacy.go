@@ -0,0 +1,25 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func PrivacyRouter(router *gin.Engine, db *gorm.DB, store storage.Storage) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	privacyHandler := handlers.NewPrivacyHandler(db, store)
+	privacy := router.Group("/users/me", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		privacy.POST("/export", privacyHandler.RequestExport)
+		privacy.GET("/exports/:exportID", privacyHandler.GetExport)
+		privacy.GET("/exports/:exportID/download", privacyHandler.DownloadExport)
+		privacy.DELETE("", privacyHandler.RequestErasure)
+		privacy.GET("/erasure", privacyHandler.GetErasure)
+		privacy.DELETE("/erasure", privacyHandler.CancelErasure)
+	}
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func ProfileRouter(router *gin.Engine, db *gorm.DB, store storage.Storage) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	profileHandler := handlers.NewProfileHandler(db, store)
+	profiles := router.Group("/users", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		profiles.GET("/by-username/:username", profileHandler.GetProfile)
+		profiles.PUT("/me/avatar", profileHandler.UploadAvatar)
+		profiles.DELETE("/me/avatar", profileHandler.DeleteAvatar)
+		profiles.PUT("/me/banner", profileHandler.UploadBanner)
+		profiles.DELETE("/me/banner", profileHandler.DeleteBanner)
+	}
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func ReactionRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	reactionHandler := handlers.NewReactionHandler(db)
+	reactions := router.Group("/posts/:id/reactions", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		reactions.GET("", reactionHandler.ListReactions)
+		reactions.PUT("/:type", reactionHandler.AddReaction)
+		reactions.DELETE("/:type", reactionHandler.RemoveReaction)
+	}
+}
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/NopparootSuree/go-social/search"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SearchRouter(router *gin.Engine, db *gorm.DB, engine search.Engine) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	searchHandler := handlers.NewSearchHandler(db, engine)
+	router.GET("/search", middlewares.OptionalJWTMiddleware(secretKey), middlewares.SessionMiddleware(db), searchHandler.Search)
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/NopparootSuree/go-social/realtime"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func StreamRouter(router *gin.Engine, db *gorm.DB, hub *realtime.Hub) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	streamHandler := handlers.NewStreamHandler(db, hub)
+	stream := router.Group("/stream", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		stream.GET("", streamHandler.Stream)
+		stream.GET("/ws", streamHandler.WebSocket)
+	}
+}
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TagRouter(router *gin.Engine, db *gorm.DB) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	tagHandler := handlers.NewTagHandler(db)
+	tags := router.Group("/tags", middlewares.OptionalJWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
+	{
+		tags.GET("/:tag/posts", tagHandler.ListTagPosts)
+	}
+}
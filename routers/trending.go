@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/NopparootSuree/go-social/handlers"
+	"github.com/NopparootSuree/go-social/middlewares"
+	"github.com/NopparootSuree/go-social/trending"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TrendingRouter(router *gin.Engine, db *gorm.DB, aggregator *trending.Aggregator) {
+	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	trendingHandler := handlers.NewTrendingHandler(db, aggregator)
+	router.GET("/trending", middlewares.OptionalJWTMiddleware(secretKey), middlewares.SessionMiddleware(db), trendingHandler.GetTrending)
+}
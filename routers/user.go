@@ -9,14 +9,22 @@ import (
 	"gorm.io/gorm"
 )
 
-func UserRouter(router *gin.Engine, db *gorm.DB) {
+func UserRouter(router *gin.Engine, db *gorm.DB) error {
 	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
-	userHandler := handlers.NewUserHandler(db)
-	users := router.Group("/users", middlewares.JWTMiddleware(secretKey))
+	userHandler, err := handlers.NewUserHandler(db)
+	if err != nil {
+		return err
+	}
+	users := router.Group("/users", middlewares.JWTMiddleware(secretKey), middlewares.SessionMiddleware(db))
 	{
 		users.GET("", userHandler.ListUsers)
+		users.GET("/trash", userHandler.ListTrash)
 		users.GET("/:id", userHandler.GetUser)
-		users.PUT("/:id", userHandler.UpdateUser)
+		users.PATCH("/:id", userHandler.UpdateUser)
 		users.DELETE("/:id", userHandler.DeleteUser)
+		users.POST("/:id/restore", userHandler.RestoreUser)
+		users.PUT("/:id/role", userHandler.ChangeRole)
+		users.PUT("/me/password", userHandler.ChangePassword)
 	}
+	return nil
 }
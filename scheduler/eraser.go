@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+)
+
+// AccountEraser ลบบัญชีที่ยืนยันการลบแล้วและพ้นช่วงเวลาที่ยกเลิกได้
+type AccountEraser struct {
+	db       *gorm.DB
+	store    storage.Storage
+	clock    clock.Clock
+	interval time.Duration
+}
+
+func NewAccountEraser(db *gorm.DB, store storage.Storage, interval time.Duration) *AccountEraser {
+	return &AccountEraser{
+		db:       db,
+		store:    store,
+		clock:    clock.New(),
+		interval: interval,
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (e *AccountEraser) WithClock(c clock.Clock) *AccountEraser {
+	e.clock = c
+	return e
+}
+
+// ลบบัญชีที่ถึงเวลาแล้ว คืนจำนวนบัญชีที่ถูกลบ
+// บัญชีที่ลบไม่สำเร็จไม่ทำให้บัญชีถัดไปค้าง error ทั้งหมดถูกรวมคืนหลังทำครบ
+// ถ้าผู้ใช้ถูกลบไปก่อนแล้ว เช่นถูก purge จากถังขยะ จะลบคำขอทิ้ง
+func (e *AccountEraser) EraseDue() (int, error) {
+	var due []models.AccountErasures
+	err := e.db.Where("confirmed_at IS NOT NULL AND erase_at <= ?", e.clock.Now()).Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	var errs []error
+	for _, erasure := range due {
+		var keys []string
+		err := e.db.Transaction(func(tx *gorm.DB) error {
+			var user models.Users
+			if err := tx.Unscoped().First(&user, erasure.UserID).Error; err != nil {
+				return err
+			}
+
+			var purgeErr error
+			keys, purgeErr = PurgeUser(tx, user)
+			return purgeErr
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = e.db.Where("userID = ?", erasure.UserID).Delete(&models.AccountErasures{}).Error
+			if err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			log.Printf("scheduler: erase user %d: %v", erasure.UserID, err)
+			errs = append(errs, fmt.Errorf("erase user %d: %w", erasure.UserID, err))
+			continue
+		}
+
+		for _, key := range keys {
+			if key == "" {
+				continue
+			}
+			if err := e.store.Delete(context.Background(), key); err != nil {
+				log.Printf("scheduler: remove blob %s: %v", key, err)
+			}
+		}
+		erased++
+	}
+	return erased, errors.Join(errs...)
+}
+
+// รันจนกว่า ctx จะถูก cancel
+func (e *AccountEraser) Start(ctx context.Context) {
+	ticker := e.clock.Ticker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := e.EraseDue(); err != nil {
+			log.Printf("scheduler: erase accounts: %v", err)
+		} else if n > 0 {
+			log.Printf("scheduler: erased %d accounts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package scheduler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+)
+
+// DataExporter สร้างไฟล์ zip ของข้อมูลส่วนตัวตามคำขอที่รออยู่ และลบไฟล์ที่หมดอายุ
+// คำขอถูกจองด้วย update แบบมีเงื่อนไข ทำให้รันหลาย instance ได้โดยไม่สร้างซ้ำ
+type DataExporter struct {
+	db       *gorm.DB
+	store    storage.Storage
+	clock    clock.Clock
+	interval time.Duration
+	ttl      time.Duration
+}
+
+func NewDataExporter(db *gorm.DB, store storage.Storage, interval, ttl time.Duration) *DataExporter {
+	return &DataExporter{
+		db:       db,
+		store:    store,
+		clock:    clock.New(),
+		interval: interval,
+		ttl:      ttl,
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (e *DataExporter) WithClock(c clock.Clock) *DataExporter {
+	e.clock = c
+	return e
+}
+
+// สร้างไฟล์ของคำขอที่รออยู่ทั้งหมด คืนจำนวนไฟล์ที่สร้างสำเร็จ
+func (e *DataExporter) ProcessPending(ctx context.Context) (int, error) {
+	var pending []models.DataExports
+	err := e.db.WithContext(ctx).Where("status = ?", models.ExportStatusPending).Order("id").Find(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for _, export := range pending {
+		result := e.db.WithContext(ctx).Model(&models.DataExports{}).
+			Where("id = ? AND status = ?", export.ID, models.ExportStatusPending).
+			Update("status", models.ExportStatusProcessing)
+		if result.Error != nil {
+			return done, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := e.process(ctx, export); err != nil {
+			log.Printf("scheduler: export %d: %v", export.ID, err)
+			e.db.Model(&models.DataExports{}).Where("id = ?", export.ID).Updates(map[string]interface{}{
+				"status": models.ExportStatusFailed,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+func (e *DataExporter) process(ctx context.Context, export models.DataExports) error {
+	var buf bytes.Buffer
+	if err := BuildArchive(e.db.WithContext(ctx), export.UserID, &buf); err != nil {
+		return err
+	}
+
+	// เก็บใน ExportPrefix ที่ storage ไม่เสิร์ฟเป็นไฟล์สาธารณะ ดาวน์โหลดผ่าน endpoint ที่ตรวจสิทธิ์แล้วเท่านั้น
+	// key สุ่มเดาไม่ได้อีกชั้นหนึ่ง
+	token, err := utils.GenerateToken(16)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s%d/%s.zip", storage.ExportPrefix, export.UserID, token)
+	if err := e.store.Put(ctx, key, bytes.NewReader(buf.Bytes()), int64(buf.Len()), "application/zip"); err != nil {
+		return err
+	}
+
+	now := e.clock.Now()
+	expiresAt := now.Add(e.ttl)
+	return e.db.WithContext(ctx).Model(&models.DataExports{}).Where("id = ?", export.ID).Updates(map[string]interface{}{
+		"status":       models.ExportStatusReady,
+		"key":          key,
+		"size":         buf.Len(),
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+// ลบไฟล์และคำขอที่หมดอายุแล้ว คืนจำนวนที่ลบ
+func (e *DataExporter) CleanupExpired(ctx context.Context) (int, error) {
+	var expired []models.DataExports
+	err := e.db.WithContext(ctx).Where("expires_at <= ?", e.clock.Now()).Find(&expired).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, export := range expired {
+		if err := e.store.Delete(ctx, export.Key); err != nil {
+			log.Printf("scheduler: remove export %s: %v", export.Key, err)
+		}
+		if err := e.db.WithContext(ctx).Delete(&export).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// รันจนกว่า ctx จะถูก cancel
+func (e *DataExporter) Start(ctx context.Context) {
+	ticker := e.clock.Ticker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := e.ProcessPending(ctx); err != nil {
+			log.Printf("scheduler: process exports: %v", err)
+		} else if n > 0 {
+			log.Printf("scheduler: built %d data exports", n)
+		}
+		if _, err := e.CleanupExpired(ctx); err != nil {
+			log.Printf("scheduler: cleanup exports: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type exportProfile struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	FullName  string    `json:"fullName"`
+	Email     string    `json:"email"`
+	Bio       string    `json:"bio"`
+	Location  string    `json:"location"`
+	Website   string    `json:"website"`
+	AvatarURL string    `json:"avatarURL"`
+	BannerURL string    `json:"bannerURL"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type exportPost struct {
+	PostID      uint       `json:"postID"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publishAt"`
+	Attachments []string   `json:"attachments"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+type exportFollow struct {
+	UserID    uint      `json:"userID"`
+	Username  string    `json:"username"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type exportFollows struct {
+	Following []exportFollow `json:"following"`
+	Followers []exportFollow `json:"followers"`
+}
+
+type exportReaction struct {
+	PostID    uint      `json:"postID"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type exportComment struct {
+	ID        uint       `json:"id"`
+	PostID    uint       `json:"postID"`
+	ParentID  *uint      `json:"parentID"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// เขียนไฟล์ zip ที่มี JSON แยกตามชนิดข้อมูล profile posts follows reactions และ comments
+func BuildArchive(db *gorm.DB, userID uint, w io.Writer) error {
+	var user models.Users
+	if err := db.Unscoped().First(&user, userID).Error; err != nil {
+		return err
+	}
+	profile := exportProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		FullName:  user.Fullname,
+		Email:     user.Email,
+		Bio:       user.Bio,
+		Location:  user.Location,
+		Website:   user.Website,
+		AvatarURL: user.AvatarURL,
+		BannerURL: user.BannerURL,
+		CreatedAt: user.CreatedAt,
+	}
+
+	var posts []models.Posts
+	if err := db.Unscoped().Where("userID = ?", userID).Order("postID").Find(&posts).Error; err != nil {
+		return err
+	}
+	var attachments []models.Attachments
+	if err := db.Where("userID = ?", userID).Order("id").Find(&attachments).Error; err != nil {
+		return err
+	}
+	urls := map[uint][]string{}
+	for _, attachment := range attachments {
+		urls[attachment.PostID] = append(urls[attachment.PostID], attachment.URL)
+	}
+	exportedPosts := make([]exportPost, 0, len(posts))
+	for _, post := range posts {
+		item := exportPost{
+			PostID:      post.PostID,
+			Title:       post.Title,
+			Body:        post.Body,
+			Status:      post.Status,
+			PublishAt:   post.PublishAt,
+			Attachments: urls[post.PostID],
+			CreatedAt:   post.CreatedAt,
+		}
+		if post.DeletedAt.Valid {
+			deletedAt := post.DeletedAt.Time
+			item.DeletedAt = &deletedAt
+		}
+		exportedPosts = append(exportedPosts, item)
+	}
+
+	follows := exportFollows{Following: []exportFollow{}, Followers: []exportFollow{}}
+	err := db.Table("follows").
+		Select("follows.followingUserID AS user_id, users.username, follows.status, follows.created_at").
+		Joins("JOIN users ON users.id = follows.followingUserID").
+		Where("follows.followerUserID = ?", userID).
+		Scan(&follows.Following).Error
+	if err != nil {
+		return err
+	}
+	err = db.Table("follows").
+		Select("follows.followerUserID AS user_id, users.username, follows.status, follows.created_at").
+		Joins("JOIN users ON users.id = follows.followerUserID").
+		Where("follows.followingUserID = ?", userID).
+		Scan(&follows.Followers).Error
+	if err != nil {
+		return err
+	}
+
+	reactions := []exportReaction{}
+	err = db.Model(&models.Reactions{}).
+		Select("postID AS post_id, type, created_at").
+		Where("userID = ?", userID).
+		Order("id").
+		Scan(&reactions).Error
+	if err != nil {
+		return err
+	}
+
+	var comments []models.Comments
+	if err := db.Where("userID = ?", userID).Order("id").Find(&comments).Error; err != nil {
+		return err
+	}
+	exportedComments := make([]exportComment, 0, len(comments))
+	for _, comment := range comments {
+		exportedComments = append(exportedComments, exportComment{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			ParentID:  comment.ParentID,
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+			DeletedAt: comment.DeletedAt,
+		})
+	}
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"posts.json", exportedPosts},
+		{"follows.json", follows},
+		{"reactions.json", reactions},
+		{"comments.json", exportedComments},
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,96 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+)
+
+// PostPublisher เปลี่ยนโพสต์ที่ตั้งเวลาไว้ให้เป็น published เมื่อถึงเวลา
+// สถานะทั้งหมดเก็บอยู่ใน database ถ้า server restart โพสต์ที่เลยเวลาจะถูก publish ในรอบแรกทันที
+type PostPublisher struct {
+	db       *gorm.DB
+	clock    clock.Clock
+	interval time.Duration
+}
+
+func NewPostPublisher(db *gorm.DB, interval time.Duration) *PostPublisher {
+	return &PostPublisher{
+		db:       db,
+		clock:    clock.New(),
+		interval: interval,
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (p *PostPublisher) WithClock(c clock.Clock) *PostPublisher {
+	p.clock = c
+	return p
+}
+
+// publish โพสต์ที่ถึงเวลาแล้ว คืนจำนวนโพสต์ที่ถูก publish
+// ใช้ update แบบมีเงื่อนไข ทำให้รันพร้อมกันหลาย instance ได้โดยไม่ publish ซ้ำ
+// ผู้ที่ถูก mention ในโพสต์จะได้รับแจ้งเตือนตอนนี้
+func (p *PostPublisher) PublishDue() (int64, error) {
+	var due []models.Posts
+	err := p.db.Where("status = ? AND publish_at <= ?", models.PostStatusScheduled, p.clock.Now()).
+		Order("publish_at").
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var published int64
+	for _, post := range due {
+		var claimed int64
+		err := p.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.Posts{}).
+				Where("postID = ? AND status = ?", post.PostID, models.PostStatusScheduled).
+				Updates(map[string]interface{}{
+					"status":  models.PostStatusPublished,
+					"version": gorm.Expr("version + 1"),
+				})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			claimed = result.RowsAffected
+
+			post.Status = models.PostStatusPublished
+			if err := events.NotifyMentions(tx, post); err != nil {
+				return err
+			}
+			postID := post.PostID
+			return events.Publish(tx, events.Event{Type: events.PostPublished, ActorID: post.UserID, PostID: &postID})
+		})
+		if err != nil {
+			return published, err
+		}
+		published += claimed
+	}
+	return published, nil
+}
+
+// รันจนกว่า ctx จะถูก cancel
+func (p *PostPublisher) Start(ctx context.Context) {
+	ticker := p.clock.Ticker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := p.PublishDue(); err != nil {
+			log.Printf("scheduler: publish due posts: %v", err)
+		} else if n > 0 {
+			log.Printf("scheduler: published %d scheduled posts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
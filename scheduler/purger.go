@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/NopparootSuree/go-social/audit"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/storage"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+)
+
+// TrashPurger ลบโพสต์และผู้ใช้ที่อยู่ในถังขยะนานเกิน retention ออกถาวร พร้อมข้อมูลที่ผูกอยู่
+type TrashPurger struct {
+	db        *gorm.DB
+	store     storage.Storage
+	clock     clock.Clock
+	retention time.Duration
+}
+
+// รันตามตาราง cron ผ่าน job queue ด้วย PurgeExpired
+func NewTrashPurger(db *gorm.DB, store storage.Storage, retention time.Duration) *TrashPurger {
+	return &TrashPurger{
+		db:        db,
+		store:     store,
+		clock:     clock.New(),
+		retention: retention,
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (p *TrashPurger) WithClock(c clock.Clock) *TrashPurger {
+	p.clock = c
+	return p
+}
+
+// ลบถาวรทุกอย่างที่หมดระยะเก็บแล้ว คืนจำนวนผู้ใช้และโพสต์ที่ถูกลบ
+// ไฟล์ใน storage ลบหลัง commit ถ้าลบไม่สำเร็จแค่ log ไว้
+func (p *TrashPurger) PurgeExpired() (users int64, posts int64, err error) {
+	cutoff := p.clock.Now().Add(-p.retention)
+
+	var expiredUsers []models.Users
+	err = p.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", cutoff).Find(&expiredUsers).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, user := range expiredUsers {
+		var keys []string
+		err = p.db.Transaction(func(tx *gorm.DB) error {
+			var purgeErr error
+			keys, purgeErr = PurgeUser(tx, user)
+			return purgeErr
+		})
+		if err != nil {
+			return users, posts, err
+		}
+		p.removeBlobs(keys)
+		users++
+	}
+
+	var postIDs []uint
+	err = p.db.Unscoped().Model(&models.Posts{}).
+		Where("deleted_at IS NOT NULL AND deleted_at <= ?", cutoff).
+		Pluck("postID", &postIDs).Error
+	if err != nil || len(postIDs) == 0 {
+		return users, posts, err
+	}
+
+	var keys []string
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		var purgeErr error
+		keys, purgeErr = PurgePosts(tx, postIDs)
+		return purgeErr
+	})
+	if err != nil {
+		return users, posts, err
+	}
+	p.removeBlobs(keys)
+
+	return users, posts + int64(len(postIDs)), nil
+}
+
+func (p *TrashPurger) removeBlobs(keys []string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := p.store.Delete(context.Background(), key); err != nil {
+			log.Printf("scheduler: remove blob %s: %v", key, err)
+		}
+	}
+}
+
+// ลบโพสต์ถาวรพร้อม revision ความคิดเห็น reaction ไฟล์แนบ tag mention และการแจ้งเตือนของโพสต์
+// คืน key ของไฟล์แนบที่ต้องลบออกจาก storage
+func PurgePosts(tx *gorm.DB, postIDs []uint) ([]string, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+
+	var attachments []models.Attachments
+	if err := tx.Where("postID IN ?", postIDs).Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(attachments)*2)
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.Key, attachment.ThumbnailKey)
+	}
+
+	children := []interface{}{
+		&models.PostRevisions{},
+		&models.Comments{},
+		&models.Reactions{},
+		&models.Attachments{},
+		&models.PostTags{},
+		&models.Mentions{},
+		&models.Notifications{},
+	}
+	for _, model := range children {
+		if err := tx.Where("postID IN ?", postIDs).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Unscoped().Where("postID IN ?", postIDs).Delete(&models.Posts{}).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ลบผู้ใช้ถาวรพร้อมโพสต์ทั้งหมด reaction mention การแจ้งเตือน การติดตาม การบล็อก session และไฟล์ส่งออกข้อมูล
+// ความคิดเห็นบนโพสต์ของคนอื่นจะถูกลบเนื้อหาแต่คงไว้ให้ thread ไม่ขาด
+// audit log ยังเก็บไว้แต่ลบชื่อ IP และค่าก่อนและหลังที่อาจมีข้อมูลส่วนตัวของผู้ใช้
+// คืน key ของไฟล์ที่ต้องลบออกจาก storage
+func PurgeUser(tx *gorm.DB, user models.Users) ([]string, error) {
+	var postIDs []uint
+	if err := tx.Unscoped().Model(&models.Posts{}).Where("userID = ?", user.ID).Pluck("postID", &postIDs).Error; err != nil {
+		return nil, err
+	}
+	keys, err := PurgePosts(tx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, user.AvatarKey, user.BannerKey)
+
+	var exportKeys []string
+	if err := tx.Model(&models.DataExports{}).Where("userID = ?", user.ID).Pluck("key", &exportKeys).Error; err != nil {
+		return nil, err
+	}
+	keys = append(keys, exportKeys...)
+
+	err = tx.Model(&models.Comments{}).
+		Where("userID = ?", user.ID).
+		Updates(map[string]interface{}{"body": "", "deleted_at": gorm.Expr("COALESCE(deleted_at, ?)", time.Now())}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pseudonymizeAuditLogs(tx, user.ID, postIDs); err != nil {
+		return nil, err
+	}
+
+	deletes := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&models.Reactions{}, "userID = ?", []interface{}{user.ID}},
+		{&models.Mentions{}, "userID = ?", []interface{}{user.ID}},
+		{&models.Notifications{}, "userID = ? OR actorID = ?", []interface{}{user.ID, user.ID}},
+		{&models.NotificationPreferences{}, "userID = ?", []interface{}{user.ID}},
+		{&models.Follows{}, "followerUserID = ? OR followingUserID = ?", []interface{}{user.ID, user.ID}},
+		{&models.Blocks{}, "blockerUserID = ? OR blockedUserID = ?", []interface{}{user.ID, user.ID}},
+		{&models.Mutes{}, "muterUserID = ? OR mutedUserID = ?", []interface{}{user.ID, user.ID}},
+		{&models.Sessions{}, "userID = ?", []interface{}{user.ID}},
+		{&models.DataExports{}, "userID = ?", []interface{}{user.ID}},
+		{&models.AccountErasures{}, "userID = ?", []interface{}{user.ID}},
+	}
+	for _, d := range deletes {
+		if err := tx.Where(d.query, d.args...).Delete(d.model).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Unscoped().Delete(&models.Users{}, user.ID).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ลบข้อมูลที่ระบุตัวผู้ใช้ออกจาก audit log ที่ผู้ใช้เป็นผู้กระทำ หรือเป็นผู้ใช้และโพสต์ของผู้ใช้ที่ถูกกระทำ
+func pseudonymizeAuditLogs(tx *gorm.DB, userID uint, postIDs []uint) error {
+	err := tx.Model(&models.AuditLogs{}).
+		Where("actorID = ?", userID).
+		Updates(map[string]interface{}{"actor_name": "", "ip": ""}).Error
+	if err != nil {
+		return err
+	}
+
+	targets := tx.Session(&gorm.Session{NewDB: true}).Where("target_type = ? AND targetID = ?", audit.TargetUser, userID)
+	if len(postIDs) > 0 {
+		targets = targets.Or("target_type = ? AND targetID IN ?", audit.TargetPost, postIDs)
+	}
+	return tx.Model(&models.AuditLogs{}).
+		Where(targets).
+		Updates(map[string]interface{}{"before": "", "after": ""}).Error
+}
@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+)
+
+// จำนวนรายการที่ส่งต่อรอบ
+const webhookBatchSize = 100
+
+// ระยะห่างสูงสุดระหว่างการส่งซ้ำ
+const webhookMaxBackoff = 24 * time.Hour
+
+// WebhookDispatcher ส่งรายการ webhook ที่ถึงกำหนด ถ้าไม่สำเร็จส่งซ้ำโดยเว้นระยะเพิ่มขึ้นเท่าตัว
+// รายการถูกจองด้วย update แบบมีเงื่อนไขที่เลื่อนเวลาส่งครั้งถัดไปออกไป ทำให้รันหลาย instance ได้
+// และถ้า instance ตายระหว่างส่ง รายการจะถูกส่งซ้ำเมื่อพ้นเวลาที่จองไว้
+type WebhookDispatcher struct {
+	db          *gorm.DB
+	client      *http.Client
+	clock       clock.Clock
+	interval    time.Duration
+	retryBase   time.Duration
+	maxAttempts int
+}
+
+func NewWebhookDispatcher(db *gorm.DB, interval, timeout, retryBase time.Duration, maxAttempts int) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:          db,
+		client:      &http.Client{Timeout: timeout},
+		clock:       clock.New(),
+		interval:    interval,
+		retryBase:   retryBase,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (d *WebhookDispatcher) WithClock(c clock.Clock) *WebhookDispatcher {
+	d.clock = c
+	return d
+}
+
+// ระยะรอก่อนส่งครั้งถัดไปหลังส่งไม่สำเร็จไปแล้ว attempts ครั้ง
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := d.retryBase
+	for i := 1; i < attempts && wait < webhookMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > webhookMaxBackoff {
+		wait = webhookMaxBackoff
+	}
+	return wait
+}
+
+// ส่งรายการที่ถึงกำหนดทั้งหมด คืนจำนวนที่ส่งสำเร็จ
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) (int, error) {
+	now := d.clock.Now()
+	var due []models.WebhookDeliveries
+	err := d.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("id").
+		Limit(webhookBatchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range due {
+		result := d.db.WithContext(ctx).Model(&models.WebhookDeliveries{}).
+			Where("id = ? AND status = ? AND attempts = ?", delivery.ID, models.WebhookDeliveryPending, delivery.Attempts).
+			Updates(map[string]interface{}{
+				"attempts":        delivery.Attempts + 1,
+				"next_attempt_at": now.Add(d.client.Timeout + d.backoff(delivery.Attempts+1)),
+			})
+		if result.Error != nil {
+			return delivered, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		delivery.Attempts++
+
+		if d.deliver(ctx, delivery) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// ส่งหนึ่งครั้งแล้วบันทึกผล คืน true ถ้าสำเร็จ
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery models.WebhookDeliveries) bool {
+	var subscription models.WebhookSubscriptions
+	err := d.db.WithContext(ctx).Where("id = ? AND active = ?", delivery.SubscriptionID, true).First(&subscription).Error
+	if err != nil {
+		return d.finish(ctx, delivery, 0, fmt.Errorf("subscription unavailable: %w", err), true)
+	}
+
+	statusCode, err := d.send(ctx, subscription, delivery)
+	if err == nil && (statusCode < 200 || statusCode >= 300) {
+		err = fmt.Errorf("unexpected status %d", statusCode)
+	}
+	return d.finish(ctx, delivery, statusCode, err, delivery.Attempts >= d.maxAttempts)
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, subscription models.WebhookSubscriptions, delivery models.WebhookDeliveries) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := d.clock.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-social-webhooks")
+	req.Header.Set("X-Webhook-Id", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", utils.SignWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// อ่านทิ้งเพื่อให้ใช้ connection ซ้ำได้
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// บันทึกผลการส่ง ถ้าไม่สำเร็จและยังไม่ครบจำนวนครั้ง รายการยังรอส่งซ้ำตามเวลาที่จองไว้
+func (d *WebhookDispatcher) finish(ctx context.Context, delivery models.WebhookDeliveries, statusCode int, sendErr error, final bool) bool {
+	updates := map[string]interface{}{"last_status_code": statusCode, "last_error": ""}
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = d.clock.Now()
+	case final:
+		updates["status"] = models.WebhookDeliveryFailed
+	default:
+		updates["next_attempt_at"] = d.clock.Now().Add(d.backoff(delivery.Attempts))
+	}
+	if sendErr != nil {
+		message := sendErr.Error()
+		if len(message) > 1024 {
+			message = message[:1024]
+		}
+		updates["last_error"] = message
+		log.Printf("scheduler: webhook delivery %d attempt %d: %v", delivery.ID, delivery.Attempts, sendErr)
+	}
+
+	err := d.db.WithContext(ctx).Model(&models.WebhookDeliveries{}).Where("id = ?", delivery.ID).Updates(updates).Error
+	if err != nil {
+		log.Printf("scheduler: record webhook delivery %d: %v", delivery.ID, err)
+	}
+	return sendErr == nil
+}
+
+// รันจนกว่า ctx จะถูก cancel
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := d.clock.Ticker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := d.DeliverDue(ctx); err != nil {
+			log.Printf("scheduler: deliver webhooks: %v", err)
+		} else if n > 0 {
+			log.Printf("scheduler: delivered %d webhooks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
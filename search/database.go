@@ -0,0 +1,129 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"gorm.io/gorm"
+)
+
+// DatabaseEngine ใช้ FULLTEXT index ของ MySQL
+type DatabaseEngine struct {
+	db *gorm.DB
+}
+
+func NewDatabaseEngine(db *gorm.DB) *DatabaseEngine {
+	return &DatabaseEngine{
+		db: db,
+	}
+}
+
+// สร้าง FULLTEXT index ที่ใช้ค้นหา ถ้ายังไม่มี
+func (e *DatabaseEngine) Migrate() error {
+	if name := e.db.Dialector.Name(); name != "mysql" {
+		return fmt.Errorf("search: unsupported database %q", name)
+	}
+
+	migrator := e.db.Migrator()
+	if !migrator.HasIndex(&models.Posts{}, "idx_posts_fulltext") {
+		if err := e.db.Exec("ALTER TABLE posts ADD FULLTEXT INDEX idx_posts_fulltext (title, body)").Error; err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&models.Users{}, "idx_users_fulltext") {
+		if err := e.db.Exec("ALTER TABLE users ADD FULLTEXT INDEX idx_users_fulltext (username, fullName)").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// เงื่อนไขค้นหาและนิพจน์คะแนน นิพจน์คะแนนรับคำค้นเป็น argument หนึ่งตัว
+// ใช้ Table จึงต้องกรองแถวที่อยู่ในถังขยะเอง
+func (e *DatabaseEngine) match(table string, columns []string, text string) (*gorm.DB, string) {
+	against := fmt.Sprintf("MATCH(%s) AGAINST (? IN NATURAL LANGUAGE MODE)", strings.Join(columns, ", "))
+	return e.db.Table(table).Where(table+".deleted_at IS NULL").Where(against, text), against
+}
+
+func (e *DatabaseEngine) SearchPosts(ctx context.Context, q Query) ([]PostHit, int64, error) {
+	query, score := e.match("posts", []string{"title", "body"}, q.Text)
+	query = query.WithContext(ctx).Scopes(models.VisiblePosts(q.ViewerID)).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		PostID    uint `gorm:"column:postID"`
+		UserID    uint `gorm:"column:userID"`
+		Title     string
+		Body      string
+		Score     float64
+		CreatedAt time.Time
+	}
+	err := query.
+		Select("posts.postID, posts.userID, posts.title, posts.body, posts.created_at, "+score+" AS score", q.Text).
+		Order("score DESC, posts.created_at DESC").
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	terms := Tokenize(q.Text)
+	hits := []PostHit{}
+	for _, row := range rows {
+		hits = append(hits, PostHit{
+			PostID:    row.PostID,
+			UserID:    row.UserID,
+			Title:     Highlight(row.Title, terms),
+			Snippet:   Highlight(row.Body, terms),
+			Score:     row.Score,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return hits, total, nil
+}
+
+func (e *DatabaseEngine) SearchUsers(ctx context.Context, q Query) ([]UserHit, int64, error) {
+	query, score := e.match("users", []string{"username", "fullName"}, q.Text)
+	query = query.WithContext(ctx).Scopes(models.NotBlocked(q.ViewerID, "users.id")).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		ID       uint
+		Username string
+		FullName string `gorm:"column:fullName"`
+		Score    float64
+	}
+	err := query.
+		Select("users.id, users.username, users.fullName, "+score+" AS score", q.Text).
+		Order("score DESC, users.id").
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	terms := Tokenize(q.Text)
+	hits := []UserHit{}
+	for _, row := range rows {
+		hits = append(hits, UserHit{
+			ID:       row.ID,
+			Username: Highlight(row.Username, terms),
+			FullName: Highlight(row.FullName, terms),
+			Score:    row.Score,
+		})
+	}
+	return hits, total, nil
+}
@@ -0,0 +1,272 @@
+package search
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"gorm.io/gorm"
+)
+
+// ค่าคงที่ของ BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// คำในชื่อเรื่องหรือ username มีน้ำหนักมากกว่าคำในเนื้อหา
+const titleWeight = 2
+
+type memoryDoc struct {
+	id        uint
+	userID    uint
+	title     string
+	body      string
+	createdAt time.Time
+	length    int
+}
+
+// index แบบ inverted index เก็บความถี่ของคำในแต่ละเอกสาร
+type memoryIndex struct {
+	docs      map[uint]memoryDoc
+	postings  map[string]map[uint]int
+	avgLength float64
+}
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{
+		docs:     map[uint]memoryDoc{},
+		postings: map[string]map[uint]int{},
+	}
+}
+
+func (idx *memoryIndex) add(doc memoryDoc) {
+	terms := map[string]int{}
+	for _, term := range Tokenize(doc.title) {
+		terms[term] += titleWeight
+	}
+	for _, term := range Tokenize(doc.body) {
+		terms[term]++
+	}
+
+	for term, tf := range terms {
+		if idx.postings[term] == nil {
+			idx.postings[term] = map[uint]int{}
+		}
+		idx.postings[term][doc.id] = tf
+		doc.length += tf
+	}
+	idx.docs[doc.id] = doc
+}
+
+func (idx *memoryIndex) finish() {
+	total := 0
+	for _, doc := range idx.docs {
+		total += doc.length
+	}
+	if len(idx.docs) > 0 {
+		idx.avgLength = float64(total) / float64(len(idx.docs))
+	}
+}
+
+type scored struct {
+	doc   memoryDoc
+	score float64
+}
+
+// ให้คะแนนแบบ BM25 และเรียงจากมากไปน้อย
+func (idx *memoryIndex) search(terms []string) []scored {
+	scores := map[uint]float64{}
+	n := float64(len(idx.docs))
+	for _, term := range terms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for id, tf := range postings {
+			doc := idx.docs[id]
+			norm := float64(tf) * (bm25K1 + 1) /
+				(float64(tf) + bm25K1*(1-bm25B+bm25B*float64(doc.length)/idx.avgLength))
+			scores[id] += idf * norm
+		}
+	}
+
+	results := make([]scored, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, scored{doc: idx.docs[id], score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].doc.createdAt.After(results[j].doc.createdAt)
+	})
+	return results
+}
+
+// MemoryEngine เก็บ index ไว้ในหน่วยความจำของ process และสร้างใหม่จาก database เป็นระยะ
+// เหมาะกับการติดตั้งขนาดเล็กหรือ database ที่ไม่มี full-text search
+type MemoryEngine struct {
+	db    *gorm.DB
+	mu    sync.RWMutex
+	posts *memoryIndex
+	users *memoryIndex
+}
+
+func NewMemoryEngine(db *gorm.DB) *MemoryEngine {
+	return &MemoryEngine{
+		db:    db,
+		posts: newMemoryIndex(),
+		users: newMemoryIndex(),
+	}
+}
+
+// สร้าง index ใหม่ทั้งหมดจาก database แล้วสลับแทน index เดิม
+func (e *MemoryEngine) Rebuild(ctx context.Context) error {
+	var posts []models.Posts
+	if err := e.db.WithContext(ctx).Find(&posts).Error; err != nil {
+		return err
+	}
+	var users []models.Users
+	if err := e.db.WithContext(ctx).Find(&users).Error; err != nil {
+		return err
+	}
+
+	postIndex := newMemoryIndex()
+	for _, post := range posts {
+		postIndex.add(memoryDoc{id: post.PostID, userID: post.UserID, title: post.Title, body: post.Body, createdAt: post.CreatedAt})
+	}
+	postIndex.finish()
+
+	userIndex := newMemoryIndex()
+	for _, user := range users {
+		userIndex.add(memoryDoc{id: user.ID, title: user.Username, body: user.Fullname, createdAt: user.CreatedAt})
+	}
+	userIndex.finish()
+
+	e.mu.Lock()
+	e.posts, e.users = postIndex, userIndex
+	e.mu.Unlock()
+	return nil
+}
+
+// สร้าง index ทันทีแล้วสร้างใหม่ทุก interval จนกว่า ctx จะถูก cancel
+func (e *MemoryEngine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Rebuild(ctx); err != nil {
+			log.Printf("search: rebuild memory index: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *MemoryEngine) SearchPosts(ctx context.Context, q Query) ([]PostHit, int64, error) {
+	terms := Tokenize(q.Text)
+	e.mu.RLock()
+	results := e.posts.search(terms)
+	e.mu.RUnlock()
+
+	if len(results) == 0 {
+		return []PostHit{}, 0, nil
+	}
+
+	// index ไม่รู้สิทธิ์การมองเห็น จึงกรองผลลัพธ์ด้วยเงื่อนไขเดียวกับ handler
+	ids := make([]uint, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.doc.id)
+	}
+	var visibleIDs []uint
+	err := e.db.WithContext(ctx).Model(&models.Posts{}).
+		Scopes(models.VisiblePosts(q.ViewerID)).
+		Where("postID IN ?", ids).
+		Pluck("postID", &visibleIDs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	visible := map[uint]bool{}
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+
+	hits := []PostHit{}
+	var total int64
+	for _, result := range results {
+		if !visible[result.doc.id] {
+			continue
+		}
+		total++
+		if total <= int64(q.Offset) || len(hits) >= q.Limit {
+			continue
+		}
+		hits = append(hits, PostHit{
+			PostID:    result.doc.id,
+			UserID:    result.doc.userID,
+			Title:     Highlight(result.doc.title, terms),
+			Snippet:   Highlight(result.doc.body, terms),
+			Score:     result.score,
+			CreatedAt: result.doc.createdAt,
+		})
+	}
+	return hits, total, nil
+}
+
+func (e *MemoryEngine) SearchUsers(ctx context.Context, q Query) ([]UserHit, int64, error) {
+	terms := Tokenize(q.Text)
+	e.mu.RLock()
+	results := e.users.search(terms)
+	e.mu.RUnlock()
+
+	if len(results) == 0 {
+		return []UserHit{}, 0, nil
+	}
+
+	// ตัดผู้ใช้ที่บล็อกกับ viewer ออก
+	ids := make([]uint, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.doc.id)
+	}
+	var visibleIDs []uint
+	err := e.db.WithContext(ctx).Model(&models.Users{}).
+		Scopes(models.NotBlocked(q.ViewerID, "users.id")).
+		Where("id IN ?", ids).
+		Pluck("id", &visibleIDs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	visible := map[uint]bool{}
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+
+	hits := []UserHit{}
+	var total int64
+	for _, result := range results {
+		if !visible[result.doc.id] {
+			continue
+		}
+		total++
+		if total <= int64(q.Offset) || len(hits) >= q.Limit {
+			continue
+		}
+		hits = append(hits, UserHit{
+			ID:       result.doc.id,
+			Username: Highlight(result.doc.title, terms),
+			FullName: Highlight(result.doc.body, terms),
+			Score:    result.score,
+		})
+	}
+	return hits, total, nil
+}
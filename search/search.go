@@ -0,0 +1,155 @@
+package search
+
+import (
+	"context"
+	"html"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// ตัวค้นหาที่รองรับ เลือกผ่าน env SEARCH_ENGINE
+const (
+	EngineDatabase = "database"
+	EngineMemory   = "memory"
+)
+
+type Query struct {
+	Text     string
+	ViewerID uint
+	Offset   int
+	Limit    int
+}
+
+type PostHit struct {
+	PostID    uint      `json:"postID"`
+	UserID    uint      `json:"userID"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type UserHit struct {
+	ID       uint    `json:"id"`
+	Username string  `json:"username"`
+	FullName string  `json:"fullName"`
+	Score    float64 `json:"score"`
+}
+
+// Engine ค้นหาโพสต์และผู้ใช้ ผลลัพธ์เรียงตามคะแนนความเกี่ยวข้อง และข้อความที่ตรงถูกครอบด้วย <mark>
+type Engine interface {
+	SearchPosts(ctx context.Context, q Query) ([]PostHit, int64, error)
+	SearchUsers(ctx context.Context, q Query) ([]UserHit, int64, error)
+}
+
+// สร้าง Engine ตาม env SEARCH_ENGINE ค่า default คือ database
+func New(db *gorm.DB) (Engine, error) {
+	if os.Getenv("SEARCH_ENGINE") == EngineMemory {
+		return NewMemoryEngine(db), nil
+	}
+
+	engine := NewDatabaseEngine(db)
+	if err := engine.Migrate(); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// แยกคำสำหรับค้นหา เป็นตัวพิมพ์เล็กทั้งหมด
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+const snippetLength = 160
+
+// ตัดข้อความรอบคำแรกที่ตรงกับคำค้น แล้วครอบคำที่ตรงด้วย <mark></mark>
+// ข้อความส่วนอื่นถูก escape เพื่อให้แสดงผลเป็น HTML ได้อย่างปลอดภัย
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	// แปลงเป็นตัวพิมพ์เล็กทีละตัวอักษร ตำแหน่งใน lower จึงตรงกับ runes เสมอ
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for i := 0; i < len(lower); {
+		matched := 0
+		// เริ่มตรงต้นคำเท่านั้น
+		if i == 0 || !isWordRune(lower[i-1]) {
+			for _, term := range terms {
+				t := []rune(term)
+				if len(t) > matched && hasPrefixRunes(lower[i:], t) {
+					matched = len(t)
+				}
+			}
+		}
+		if matched > 0 {
+			spans = append(spans, span{i, i + matched})
+			i += matched
+			continue
+		}
+		i++
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > snippetLength {
+		if len(spans) > 0 {
+			start = spans[0].start - snippetLength/4
+			if start < 0 {
+				start = 0
+			}
+		}
+		end = start + snippetLength
+		if end > len(runes) {
+			end = len(runes)
+		}
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	pos := start
+	for _, s := range spans {
+		if s.end <= start || s.start >= end {
+			continue
+		}
+		if s.start < pos || s.end > end {
+			continue
+		}
+		sb.WriteString(html.EscapeString(string(runes[pos:s.start])))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(string(runes[s.start:s.end])))
+		sb.WriteString("</mark>")
+		pos = s.end
+	}
+	sb.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+func hasPrefixRunes(s, prefix []rune) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
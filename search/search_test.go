@@ -0,0 +1,20 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "go &amp; <mark>Gopher</mark>s", Highlight("go & Gophers", Tokenize("gopher")))
+
+	// ต้องเริ่มตรงต้นคำ
+	assert.Equal(t, "ungopher", Highlight("ungopher", Tokenize("gopher")))
+}
+
+func TestHighlightUnicodeCase(t *testing.T) {
+	// ตัวอักษรที่ไม่ใช่ ASCII ก่อนคำที่ตรง ต้องไม่ทำให้ตำแหน่งที่ครอบเลื่อน
+	assert.Equal(t, "İstanbul trip to <mark>Paris</mark>", Highlight("İstanbul trip to Paris", Tokenize("paris")))
+	assert.Equal(t, "<mark>İstanbul</mark> trip", Highlight("İstanbul trip", Tokenize("İSTANBUL")))
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local เก็บไฟล์ไว้ใน directory ของเครื่อง และให้ gin เสิร์ฟไฟล์ผ่าน URLPrefix
+type Local struct {
+	Dir       string
+	URLPrefix string
+}
+
+func NewLocal(dir, urlPrefix string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{
+		Dir:       dir,
+		URLPrefix: strings.TrimRight(urlPrefix, "/"),
+	}, nil
+}
+
+// แปลง key เป็น path ในเครื่อง กัน key ที่พยายามออกนอก directory
+func (s *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("storage: invalid key")
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}
+
+func (s *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// เขียนลงไฟล์ชั่วคราวก่อนแล้วค่อย rename เพื่อไม่ให้มีไฟล์ที่เขียนไม่ครบ
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *Local) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Local) URL(key string) string {
+	return s.URLPrefix + "/" + strings.TrimLeft(key, "/")
+}
+
+// Handler เสิร์ฟไฟล์ตาม path หลัง URLPrefix ยกเว้นไฟล์ส่งออกข้อมูลใน ExportPrefix และไม่แสดงรายการใน directory
+func (s *Local) Handler() http.Handler {
+	files := http.FileServer(onlyFiles{http.Dir(s.Dir)})
+	return http.StripPrefix(s.URLPrefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clean := strings.ToLower(path.Clean("/"+r.URL.Path) + "/")
+		if strings.HasPrefix(clean, "/"+ExportPrefix) {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+}
+
+// ไม่ให้เปิด directory เพื่อไม่ให้ FileServer แสดงรายการไฟล์
+type onlyFiles struct {
+	fs http.FileSystem
+}
+
+func (o onlyFiles) Open(name string) (http.File, error) {
+	f, err := o.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
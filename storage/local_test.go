@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalHandlerHidesExports(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	for _, key := range []string{"posts/1/a.jpg", ExportPrefix + "1/token.zip"} {
+		assert.NoError(t, store.Put(context.Background(), key, bytes.NewReader([]byte("data")), 4, ""))
+	}
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		store.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/media/posts/1/a.jpg"))
+	// ไฟล์ส่งออกข้อมูลและรายการใน directory ไม่ถูกเสิร์ฟ
+	assert.Equal(t, http.StatusNotFound, get("/media/exports/1/token.zip"))
+	assert.Equal(t, http.StatusNotFound, get("/media/posts/../exports/1/token.zip"))
+	assert.Equal(t, http.StatusNotFound, get("/media/EXPORTS/1/token.zip"))
+	assert.Equal(t, http.StatusNotFound, get("/media/exports/1/"))
+	assert.Equal(t, http.StatusNotFound, get("/media/posts/1/"))
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// prefix ของไฟล์ส่งออกข้อมูลส่วนตัว ต้องอ่านผ่าน Get ใน endpoint ที่ตรวจสิทธิ์แล้วเท่านั้น
+// Local ไม่เสิร์ฟไฟล์เหล่านี้ ถ้าใช้ S3 bucket ต้องไม่เปิด prefix นี้ให้อ่านแบบสาธารณะ
+const ExportPrefix = "exports/"
+
+// Storage เก็บไฟล์ (blob) ตาม key เช่น posts/1/abc.jpg
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL ที่ client ใช้เปิดไฟล์
+	URL(key string) string
+}
+
+// สร้าง Storage ตาม env STORAGE_DRIVER (local หรือ s3) ค่า default คือ local
+func New() (Storage, error) {
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		return NewLocal(dir, "/media")
+	default:
+		return nil, errors.New("storage: unknown STORAGE_DRIVER " + os.Getenv("STORAGE_DRIVER"))
+	}
+}
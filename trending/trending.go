@@ -0,0 +1,417 @@
+package trending
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/benbjohnson/clock"
+	"gorm.io/gorm"
+)
+
+// ช่วงเวลาที่คำนวณ trending
+type Window string
+
+const (
+	WindowHour Window = "hour"
+	WindowDay  Window = "day"
+	WindowWeek Window = "week"
+)
+
+var windows = map[Window]time.Duration{
+	WindowHour: time.Hour,
+	WindowDay:  24 * time.Hour,
+	WindowWeek: 7 * 24 * time.Hour,
+}
+
+func ParseWindow(value string) (Window, bool) {
+	_, ok := windows[Window(value)]
+	return Window(value), ok
+}
+
+// น้ำหนักของกิจกรรมแต่ละแบบ
+const (
+	weightPost     = 3.0
+	weightComment  = 2.0
+	weightReaction = 1.0
+)
+
+// กิจกรรมถูกรวมเป็นช่วงละ bucketSize ทำให้ใช้หน่วยความจำคงที่ไม่ว่ากิจกรรมจะมากแค่ไหน
+const bucketSize = 5 * time.Minute
+
+// จำนวนอันดับที่เก็บไว้ใน cache ต่อหนึ่งช่วงเวลา
+const MaxItems = 50
+
+// แต่ละรอบอ่านย้อนไปก่อนรอบที่แล้วเท่านี้ เพื่อให้เจอกิจกรรมที่ commit ช้ากว่ากิจกรรมที่อ่านไปแล้ว
+// และโพสต์ตั้งเวลาที่ถูก publish หลัง publish_at ต้องนานกว่า POST_PUBLISH_INTERVAL
+const overlap = 10 * time.Minute
+
+// id ของกิจกรรมที่นับแล้วกับเวลาของกิจกรรม กันการนับซ้ำเมื่อช่วงที่อ่านซ้อนกัน
+type seenSet map[uint]time.Time
+
+// ลบ id ที่เวลาไม่เกิน cutoff ซึ่งรอบถัดไปจะไม่อ่านอีกแล้ว
+func (s seenSet) prune(cutoff time.Time) {
+	for id, at := range s {
+		if !at.After(cutoff) {
+			delete(s, id)
+		}
+	}
+}
+
+// คะแนนของ hashtag คำนวณจากคะแนนโพสต์ตอน compute เพื่อให้นับเฉพาะโพสต์ที่ทุกคนมองเห็น
+type bucket struct {
+	posts map[uint]float64
+}
+
+type TagScore struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+type PostScore struct {
+	PostID uint    `json:"postID"`
+	Score  float64 `json:"score"`
+}
+
+type Snapshot struct {
+	Window      Window      `json:"window"`
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Tags        []TagScore  `json:"hashtags"`
+	Posts       []PostScore `json:"posts"`
+}
+
+// Aggregator อ่านเฉพาะกิจกรรมใหม่ (โพสต์ ความคิดเห็น reaction) ตั้งแต่รอบก่อน
+// รวมคะแนนไว้ในหน่วยความจำ แล้วคำนวณอันดับเก็บเป็น cache ทุก refresh interval
+// คะแนนลดลงแบบ exponential ตามอายุของกิจกรรม โดย half-life เป็นหนึ่งในสี่ของช่วงเวลา
+type Aggregator struct {
+	db       *gorm.DB
+	clock    clock.Clock
+	interval time.Duration
+
+	mu            sync.RWMutex
+	buckets       map[int64]*bucket
+	postTags      map[uint][]string
+	lastRefresh   time.Time
+	seenPosts     seenSet
+	seenComments  seenSet
+	seenReactions seenSet
+	snapshots     map[Window]Snapshot
+	backfilled    bool
+}
+
+func NewAggregator(db *gorm.DB, interval time.Duration) *Aggregator {
+	return &Aggregator{
+		db:            db,
+		clock:         clock.New(),
+		interval:      interval,
+		buckets:       map[int64]*bucket{},
+		postTags:      map[uint][]string{},
+		seenPosts:     seenSet{},
+		seenComments:  seenSet{},
+		seenReactions: seenSet{},
+		snapshots:     map[Window]Snapshot{},
+	}
+}
+
+// ใช้เปลี่ยน clock ตอนทดสอบ
+func (a *Aggregator) WithClock(c clock.Clock) *Aggregator {
+	a.clock = c
+	return a
+}
+
+// ผลลัพธ์ล่าสุดใน cache
+func (a *Aggregator) Snapshot(window Window) Snapshot {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	snapshot, ok := a.snapshots[window]
+	if !ok {
+		return Snapshot{Window: window, Tags: []TagScore{}, Posts: []PostScore{}}
+	}
+	return snapshot
+}
+
+// รันจนกว่า ctx จะถูก cancel
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := a.clock.Ticker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Refresh(ctx); err != nil {
+			log.Printf("trending: refresh: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type activity struct {
+	id        uint
+	postID    uint
+	createdAt time.Time
+}
+
+// อ่านกิจกรรมใหม่ รวมเข้า bucket แล้วคำนวณ cache ใหม่
+// รอบแรกจะอ่านย้อนหลังหนึ่งสัปดาห์ รอบต่อๆ ไปอ่านกิจกรรมที่เกิดหลังรอบก่อนลบด้วย overlap
+// โพสต์นับจากเวลาที่ publish ส่วนความคิดเห็นและ reaction นับจากเวลาที่สร้าง
+func (a *Aggregator) Refresh(ctx context.Context) error {
+	now := a.clock.Now()
+	since := now.Add(-windows[WindowWeek])
+	db := a.db.WithContext(ctx)
+
+	postQuery := db.Model(&models.Posts{}).
+		Select("postID AS id, postID AS post_id, publish_at AS created_at").
+		Where("status = ? AND publish_at <= ?", models.PostStatusPublished, now)
+	commentQuery := db.Model(&models.Comments{}).
+		Select("id, postID AS post_id, created_at").
+		Where("deleted_at IS NULL")
+	reactionQuery := db.Model(&models.Reactions{}).
+		Select("id, postID AS post_id, created_at")
+
+	if a.backfilled {
+		from := a.lastRefresh.Add(-overlap)
+		postQuery = postQuery.Where("publish_at > ?", from)
+		commentQuery = commentQuery.Where("created_at > ?", from)
+		reactionQuery = reactionQuery.Where("created_at > ?", from)
+	} else {
+		postQuery = postQuery.Where("publish_at >= ?", since)
+		commentQuery = commentQuery.Where("created_at >= ?", since)
+		reactionQuery = reactionQuery.Where("created_at >= ?", since)
+	}
+
+	posts, err := loadActivity(postQuery)
+	if err != nil {
+		return err
+	}
+	comments, err := loadActivity(commentQuery)
+	if err != nil {
+		return err
+	}
+	reactions, err := loadActivity(reactionQuery)
+	if err != nil {
+		return err
+	}
+
+	postIDs := map[uint]bool{}
+	for _, group := range [][]activity{posts, comments, reactions} {
+		for _, item := range group {
+			postIDs[item.postID] = true
+		}
+	}
+	tags, err := tagsOf(db, postIDs)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// hashtag ปัจจุบันของโพสต์ที่มีกิจกรรมใหม่
+	for id := range postIDs {
+		a.postTags[id] = tags[id]
+	}
+	a.add(posts, weightPost, since, a.seenPosts)
+	a.add(comments, weightComment, since, a.seenComments)
+	a.add(reactions, weightReaction, since, a.seenReactions)
+	for _, seen := range []seenSet{a.seenPosts, a.seenComments, a.seenReactions} {
+		seen.prune(now.Add(-overlap))
+	}
+	a.lastRefresh = now
+	a.backfilled = true
+
+	// ลบ bucket ที่เก่ากว่าช่วงเวลาที่ยาวที่สุด และ hashtag ของโพสต์ที่ไม่อยู่ใน bucket ใดแล้ว
+	for start := range a.buckets {
+		if time.Unix(start, 0).Before(since.Add(-bucketSize)) {
+			delete(a.buckets, start)
+		}
+	}
+	active := a.activePosts()
+	for id := range a.postTags {
+		if !active[id] {
+			delete(a.postTags, id)
+		}
+	}
+
+	published, err := publishedPosts(db, active)
+	if err != nil {
+		return err
+	}
+	for window := range windows {
+		a.snapshots[window] = a.compute(window, now, published)
+	}
+	return nil
+}
+
+func loadActivity(query *gorm.DB) ([]activity, error) {
+	var rows []struct {
+		ID        uint
+		PostID    uint
+		CreatedAt time.Time
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]activity, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, activity{id: row.ID, postID: row.PostID, createdAt: row.CreatedAt})
+	}
+	return items, nil
+}
+
+// hashtag ของโพสต์ที่มีกิจกรรมใหม่
+func tagsOf(db *gorm.DB, postIDs map[uint]bool) (map[uint][]string, error) {
+	result := map[uint][]string{}
+	if len(postIDs) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, 0, len(postIDs))
+	for id := range postIDs {
+		ids = append(ids, id)
+	}
+
+	var rows []struct {
+		PostID uint `gorm:"column:postID"`
+		Name   string
+	}
+	err := db.Table("post_tags").
+		Select("post_tags.postID, tags.name").
+		Joins("JOIN tags ON tags.id = post_tags.tagID").
+		Where("post_tags.postID IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.PostID] = append(result[row.PostID], row.Name)
+	}
+	return result, nil
+}
+
+// รวมกิจกรรมที่ยังไม่เคยนับเข้า bucket
+func (a *Aggregator) add(items []activity, weight float64, since time.Time, seen seenSet) {
+	for _, item := range items {
+		if _, ok := seen[item.id]; ok {
+			continue
+		}
+		seen[item.id] = item.createdAt
+		if item.createdAt.Before(since) {
+			continue
+		}
+
+		start := item.createdAt.Truncate(bucketSize).Unix()
+		b, ok := a.buckets[start]
+		if !ok {
+			b = &bucket{posts: map[uint]float64{}}
+			a.buckets[start] = b
+		}
+		b.posts[item.postID] += weight
+	}
+}
+
+// โพสต์ที่มีคะแนนอยู่ใน bucket ใด bucket หนึ่ง
+func (a *Aggregator) activePosts() map[uint]bool {
+	ids := map[uint]bool{}
+	for _, b := range a.buckets {
+		for id := range b.posts {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// โพสต์ใน ids ที่ผู้ที่ไม่ได้ login มองเห็นได้ ใช้กรองโพสต์ที่ไม่ใช่ public ถูกซ่อนหรือลบไปแล้วออกจากอันดับ
+func publishedPosts(db *gorm.DB, ids map[uint]bool) (map[uint]bool, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	candidates := make([]uint, 0, len(ids))
+	for id := range ids {
+		candidates = append(candidates, id)
+	}
+
+	var visible []uint
+	err := db.Model(&models.Posts{}).
+		Scopes(models.VisiblePosts(0)).
+		Where("postID IN ?", candidates).
+		Pluck("postID", &visible).Error
+	if err != nil {
+		return nil, err
+	}
+
+	published := map[uint]bool{}
+	for _, id := range visible {
+		published[id] = true
+	}
+	return published, nil
+}
+
+func (a *Aggregator) compute(window Window, now time.Time, published map[uint]bool) Snapshot {
+	length := windows[window]
+	halfLife := length / 4
+	from := now.Add(-length)
+
+	posts := map[uint]float64{}
+	tags := map[string]float64{}
+	for start, b := range a.buckets {
+		at := time.Unix(start, 0)
+		if at.Before(from.Truncate(bucketSize)) {
+			continue
+		}
+		age := now.Sub(at)
+		if age < 0 {
+			age = 0
+		}
+		decay := math.Exp(-math.Ln2 * float64(age) / float64(halfLife))
+
+		// โพสต์ที่ไม่ใช่ public หรือถูกซ่อนไปแล้วไม่นับทั้งในอันดับโพสต์และ hashtag
+		for id, score := range b.posts {
+			if !published[id] {
+				continue
+			}
+			posts[id] += score * decay
+			for _, tag := range a.postTags[id] {
+				tags[tag] += score * decay
+			}
+		}
+	}
+
+	snapshot := Snapshot{Window: window, GeneratedAt: now, Tags: []TagScore{}, Posts: []PostScore{}}
+	for id, score := range posts {
+		snapshot.Posts = append(snapshot.Posts, PostScore{PostID: id, Score: score})
+	}
+	for tag, score := range tags {
+		snapshot.Tags = append(snapshot.Tags, TagScore{Tag: tag, Score: score})
+	}
+
+	sort.Slice(snapshot.Posts, func(i, j int) bool {
+		if snapshot.Posts[i].Score != snapshot.Posts[j].Score {
+			return snapshot.Posts[i].Score > snapshot.Posts[j].Score
+		}
+		return snapshot.Posts[i].PostID > snapshot.Posts[j].PostID
+	})
+	sort.Slice(snapshot.Tags, func(i, j int) bool {
+		if snapshot.Tags[i].Score != snapshot.Tags[j].Score {
+			return snapshot.Tags[i].Score > snapshot.Tags[j].Score
+		}
+		return snapshot.Tags[i].Tag < snapshot.Tags[j].Tag
+	})
+	if len(snapshot.Posts) > MaxItems {
+		snapshot.Posts = snapshot.Posts[:MaxItems]
+	}
+	if len(snapshot.Tags) > MaxItems {
+		snapshot.Tags = snapshot.Tags[:MaxItems]
+	}
+	return snapshot
+}
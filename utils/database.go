@@ -9,19 +9,54 @@ import (
 	"gorm.io/gorm"
 )
 
+// เชื่อมต่อฐานข้อมูลแล้วสร้างหรือปรับตารางให้ตรงกับ models
 func ConnectDatabase() (*gorm.DB, error) {
+	db, err := OpenDatabase()
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// เชื่อมต่อฐานข้อมูลตาม env DB_* โดยไม่แตะโครงสร้างตาราง
+func OpenDatabase() (*gorm.DB, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		os.Getenv("DB_USER"),
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_PORT"),
 		os.Getenv("DB_NAME"))
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		return nil, err
-	}
-
-	db.AutoMigrate(&models.Users{}, &models.Posts{}, &models.Follows{})
+	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+}
 
-	return db, nil
+// สร้างหรือปรับตารางทั้งหมดให้ตรงกับ models
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.Users{},
+		&models.Sessions{},
+		&models.Posts{},
+		&models.PostRevisions{},
+		&models.Comments{},
+		&models.Reactions{},
+		&models.Attachments{},
+		&models.Tags{},
+		&models.PostTags{},
+		&models.Mentions{},
+		&models.Notifications{},
+		&models.NotificationPreferences{},
+		&models.Follows{},
+		&models.Blocks{},
+		&models.Mutes{},
+		&models.DataExports{},
+		&models.AccountErasures{},
+		&models.WebhookSubscriptions{},
+		&models.WebhookDeliveries{},
+		&models.OutboxEvents{},
+		&models.Jobs{},
+		&models.JobSchedules{},
+		&models.AuditLogs{},
+	)
 }
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// จำนวนช่องสูงสุดของตาราง LCS ประมาณ 8 MB ถ้าเกินจะไม่สร้าง diff
+const maxDiffCells = 1 << 20
+
+var ErrDiffTooLarge = errors.New("texts are too large to diff")
+
+// สร้าง diff ระหว่างข้อความสองชุด เทียบทีละบรรทัด
+// บรรทัดที่ขึ้นต้นด้วย "-" คือถูกลบ "+" คือเพิ่มใหม่ และ " " คือเหมือนเดิม
+// ตาราง LCS ใช้หน่วยความจำตามจำนวนบรรทัดที่ต่างกันคูณกัน ถ้าใหญ่เกินไปคืน ErrDiffTooLarge
+func LineDiff(fromName, toName, from, to string) (string, error) {
+	a := strings.Split(from, "\n")
+	b := strings.Split(to, "\n")
+
+	// บรรทัดที่เหมือนกันตอนต้นและตอนท้ายไม่ต้องเข้าตาราง
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+		return "", ErrDiffTooLarge
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, line := range a[:prefix] {
+		sb.WriteString(" " + line + "\n")
+	}
+	writeLineDiff(&sb, midA, midB)
+	for _, line := range a[len(a)-suffix:] {
+		sb.WriteString(" " + line + "\n")
+	}
+
+	return sb.String(), nil
+}
+
+func writeLineDiff(sb *strings.Builder, a, b []string) {
+	// ตาราง LCS ของบรรทัด
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		// ถ้าเลือกได้ทั้งสองทางให้แสดงบรรทัดที่ถูกลบก่อนบรรทัดที่เพิ่ม
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+}
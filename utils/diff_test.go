@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineDiff(t *testing.T) {
+	diff, err := LineDiff("a", "b", "title\none\ntwo\nend", "title\none\nthree\nend")
+	assert.NoError(t, err)
+	assert.Equal(t, "--- a\n+++ b\n title\n one\n-two\n+three\n end\n", diff)
+
+	// บรรทัดที่ถูกแทนหลายบรรทัด ลบทั้งหมดก่อนแล้วจึงเพิ่ม
+	diff, err = LineDiff("a", "b", "keep\nold1\nold2", "keep\nnew1\nnew2")
+	assert.NoError(t, err)
+	assert.Equal(t, "--- a\n+++ b\n keep\n-old1\n-old2\n+new1\n+new2\n", diff)
+
+	diff, err = LineDiff("a", "b", "same\ntext", "same\ntext")
+	assert.NoError(t, err)
+	assert.Equal(t, "--- a\n+++ b\n same\n text\n", diff)
+}
+
+func TestLineDiffTooLarge(t *testing.T) {
+	lines := func(prefix string, n int) string {
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			sb.WriteString(prefix + "\n")
+		}
+		return sb.String()
+	}
+
+	// ข้อความที่ต่างกันทั้งหมดหลายพันบรรทัดไม่สร้างตาราง
+	_, err := LineDiff("a", "b", lines("x", 2000), lines("y", 2000))
+	assert.ErrorIs(t, err, ErrDiffTooLarge)
+
+	// ข้อความยาวที่ต่างกันเพียงบรรทัดเดียวยัง diff ได้
+	_, err = LineDiff("a", "b", lines("x", 5000)+"old", lines("x", 5000)+"new")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// อ่าน duration จาก env ถ้าไม่มีหรือผิดรูปแบบใช้ค่า fallback
+func DurationEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// อ่านตัวเลขจาก env ถ้าไม่มีหรือผิดรูปแบบใช้ค่า fallback
+func IntEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// อ่านข้อความจาก env ถ้าไม่มีใช้ค่า fallback
+func StringEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// อ่านรายการที่คั่นด้วย comma จาก env ถ้าไม่มีคืน nil
+func ListEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// ระยะเวลาที่เก็บโพสต์และผู้ใช้ที่ถูกลบไว้ในถังขยะก่อนลบถาวร
+func TrashRetention() time.Duration {
+	return DurationEnv("TRASH_RETENTION", 30*24*time.Hour)
+}
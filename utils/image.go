@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+var ErrUnsupportedImage = errors.New("unsupported image type")
+
+// ชนิดรูปที่รับ upload
+var ImageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// decode รูปแล้ว encode ใหม่ ทำให้ metadata ทั้งหมด (EXIF, GPS ฯลฯ) หายไป
+// รูป JPEG จะถูกหมุนตาม EXIF orientation ก่อน เพื่อให้แสดงผลเหมือนเดิม
+func SanitizeImage(data []byte, contentType string) ([]byte, image.Image, error) {
+	switch contentType {
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		img = applyOrientation(img, jpegOrientation(data))
+		out, err := EncodeImage(img, contentType)
+		return out, img, err
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		out, err := EncodeImage(img, contentType)
+		return out, img, err
+	case "image/gif":
+		// encode ใหม่ทุก frame เพื่อคง animation แต่ตัด extension อื่นๆ ออก
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			return nil, nil, err
+		}
+		return buf.Bytes(), g.Image[0], nil
+	default:
+		return nil, nil, ErrUnsupportedImage
+	}
+}
+
+// encode รูป PNG คงเป็น PNG เพื่อรักษาความโปร่งใส นอกนั้นเป็น JPEG
+func EncodeImage(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	return buf.Bytes(), err
+}
+
+// ชนิดไฟล์ของ thumbnail ที่สร้างจากรูปต้นฉบับ
+func ThumbnailContentType(contentType string) string {
+	if contentType == "image/png" {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+// ย่อรูปให้ด้านที่ยาวที่สุดไม่เกิน maxSize โดยคงสัดส่วนเดิม รูปที่เล็กกว่าอยู่แล้วจะไม่ถูกขยาย
+func Thumbnail(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return Resize(img, w, h)
+	}
+	if w >= h {
+		return Resize(img, maxSize, maxInt(1, h*maxSize/w))
+	}
+	return Resize(img, maxInt(1, w*maxSize/h), maxSize)
+}
+
+// ครอปกึ่งกลางให้ได้สัดส่วน width:height แล้วย่อเป็นขนาดนั้นพอดี
+func ResizeToFill(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	cropW, cropH := w, w*height/width
+	if cropH > h {
+		cropW, cropH = h*width/height, h
+	}
+	x0 := b.Min.X + (w-cropW)/2
+	y0 := b.Min.Y + (h-cropH)/2
+
+	cropped := image.NewRGBA(image.Rect(0, 0, cropW, cropH))
+	draw.Draw(cropped, cropped.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	return Resize(cropped, width, height)
+}
+
+// ย่อ/ขยายรูปแบบเฉลี่ยพื้นที่ (box filter) ให้ผลลัพธ์เรียบกว่า nearest neighbor
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := maxInt(y0+1, (y+1)*sh/height)
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := maxInt(x0+1, (x+1)*sw/width)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := src.RGBAAt(sx, sy)
+					r += uint32(c.R)
+					g += uint32(c.G)
+					b += uint32(c.B)
+					a += uint32(c.A)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// อ่านค่า orientation จาก EXIF ของไฟล์ JPEG ถ้าไม่มีคืน 1 (ไม่ต้องหมุน)
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// หมุน/กลับรูปตามค่า EXIF orientation 1-8
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,382 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrPatchTestFailed คือ operation test ของ JSON Patch ไม่ผ่าน
+	ErrPatchTestFailed = errors.New("json patch test operation failed")
+	errPathNotFound    = errors.New("path not found")
+)
+
+// ใช้ JSON Merge Patch (RFC 7396) กับเอกสาร
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergeValue(t[key], value)
+		}
+	}
+	return t
+}
+
+// ใช้ JSON Patch (RFC 6902) กับเอกสาร ทุก operation ต้องผ่านทั้งหมดถึงจะได้ผลลัพธ์
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// ใช้ RawMessage เพื่อแยก value ที่เป็น null ออกจากไม่ได้ส่ง value มา
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid json patch: %w", err)
+	}
+
+	for i, op := range ops {
+		target, err = applyPatchOp(target, op)
+		if err != nil {
+			if errors.Is(err, ErrPatchTestFailed) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyPatchOp(doc interface{}, op map[string]json.RawMessage) (interface{}, error) {
+	var name, path string
+	if err := unmarshalMember(op, "op", &name); err != nil {
+		return nil, err
+	}
+	if err := unmarshalMember(op, "path", &path); err != nil {
+		return nil, err
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		raw, ok := op["value"]
+		if !ok {
+			return nil, fmt.Errorf("%s requires a value", name)
+		}
+		value, err := decodeJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "add":
+			return addValue(doc, tokens, value)
+		case "replace":
+			if doc, err = removeValue(doc, tokens); err != nil {
+				return nil, err
+			}
+			return addValue(doc, tokens, value)
+		default:
+			current, err := getValue(doc, tokens)
+			if err != nil {
+				return nil, err
+			}
+			if !jsonEqual(current, value) {
+				return nil, ErrPatchTestFailed
+			}
+			return doc, nil
+		}
+	case "remove":
+		return removeValue(doc, tokens)
+	case "move", "copy":
+		var from string
+		if err := unmarshalMember(op, "from", &from); err != nil {
+			return nil, err
+		}
+		fromTokens, err := parsePointer(from)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "copy" {
+			return addValue(doc, tokens, copyValue(value))
+		}
+		if path != from && strings.HasPrefix(path, from+"/") {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		if doc, err = removeValue(doc, fromTokens); err != nil {
+			return nil, err
+		}
+		return addValue(doc, tokens, value)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+}
+
+func unmarshalMember(op map[string]json.RawMessage, key string, dst *string) error {
+	raw, ok := op[key]
+	if !ok {
+		return fmt.Errorf("missing %q", key)
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("invalid %q: %w", key, err)
+	}
+	return nil
+}
+
+// แยก JSON Pointer (RFC 6901) เป็นส่วน ๆ
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// แปลง token เป็น index ของ array ถ้า allowEnd จะยอมให้ index เท่ากับความยาวหรือเป็น "-"
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("array index %d: %w", index, errPathNotFound)
+	}
+	return index, nil
+}
+
+func getValue(doc interface{}, tokens []string) (interface{}, error) {
+	node := doc
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%q: %w", token, errPathNotFound)
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("%q: %w", token, errPathNotFound)
+		}
+	}
+	return node, nil
+}
+
+// เดินไปที่ parent ของ path แล้วเรียก change กับ parent นั้น คืนเอกสารที่แก้แล้ว
+func updateParent(node interface{}, tokens []string, change func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return change(node, tokens[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("%q: %w", tokens[0], errPathNotFound)
+		}
+		updated, err := updateParent(child, tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = updated
+		return n, nil
+	case []interface{}:
+		index, err := arrayIndex(tokens[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateParent(n[index], tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%q: %w", tokens[0], errPathNotFound)
+	}
+}
+
+func addValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return updateParent(doc, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(p), true)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[index+1:], p[index:])
+			p[index] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("%q: %w", key, errPathNotFound)
+		}
+	})
+}
+
+func removeValue(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	return updateParent(doc, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("%q: %w", key, errPathNotFound)
+			}
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(p), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:index], p[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%q: %w", key, errPathNotFound)
+		}
+	})
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = copyValue(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = copyValue(child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// เทียบค่า JSON สองค่า ตัวเลขเทียบกันตามค่า ไม่ใช่ตามรูปแบบที่เขียน
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		if errX != nil || errY != nil {
+			return x == y
+		}
+		return fx == fy
+	default:
+		return a == b
+	}
+}
+
+// JSONEqual เทียบเอกสาร JSON สองชุดตามค่า
+func JSONEqual(a, b []byte) bool {
+	x, errX := decodeJSON(a)
+	y, errY := decodeJSON(b)
+	return errX == nil && errY == nil && jsonEqual(x, y)
+}
+
+// อ่าน JSON โดยเก็บตัวเลขไว้เป็น json.Number เพื่อไม่ให้ความละเอียดหาย
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after json value")
+	}
+	return value, nil
+}
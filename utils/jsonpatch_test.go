@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// ตัวอย่างจาก RFC 7396
+	out, err := MergePatch(
+		[]byte(`{"title": "Goodbye!", "author": {"givenName": "John", "familyName": "Doe"}, "tags": ["example", "sample"], "content": "This will be unchanged"}`),
+		[]byte(`{"title": "Hello!", "phoneNumber": "+01-123-456-7890", "author": {"familyName": null}, "tags": ["example"]}`),
+	)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"title": "Hello!", "author": {"givenName": "John"}, "tags": ["example"], "content": "This will be unchanged", "phoneNumber": "+01-123-456-7890"}`, string(out))
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	// ตัวอย่างจาก RFC 6902
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo": ["all", "cows", "eat", "grass"]}`},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo": ["bar", ["abc", "def"]]}`},
+		{`{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10.0}, {"op": "copy", "from": "/~01", "path": "/a"}]`, `{"/": 9, "~1": 10, "a": 10}`},
+	}
+	for _, tt := range tests {
+		out, err := ApplyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+		assert.NoError(t, err, tt.patch)
+		assert.JSONEq(t, tt.want, string(out), tt.patch)
+	}
+
+	_, err := ApplyJSONPatch([]byte(`{"baz": "qux"}`), []byte(`[{"op": "test", "path": "/baz", "value": "bar"}]`))
+	assert.True(t, errors.Is(err, ErrPatchTestFailed))
+
+	_, err = ApplyJSONPatch([]byte(`{"foo": "bar"}`), []byte(`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`))
+	assert.Error(t, err)
+}
@@ -1,6 +1,15 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 func HashPassword(password string) (string, error) {
 	// GenerateFromPassword จะรับพาสเวิร์ดเป็นไบต์และคืนเป็นไบต์แฮชและข้อผิดพลาด
@@ -16,3 +25,75 @@ func ComparePasswords(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
+
+var (
+	ErrPasswordTooShort     = errors.New("password is too short")
+	ErrPasswordTooLong      = errors.New("password is too long")
+	ErrPasswordBreached     = errors.New("password has appeared in a data breach")
+	ErrPasswordSameUsername = errors.New("password must not equal the username")
+)
+
+// bcrypt ใช้แค่ 72 ไบต์แรก ยาวกว่านี้ไม่มีประโยชน์
+const maxPasswordLength = 72
+
+// เงื่อนไขของรหัสผ่าน
+type PasswordPolicy struct {
+	MinLength int
+	breached  map[string]struct{}
+}
+
+// อ่านเงื่อนไขจาก env PASSWORD_MIN_LENGTH และ PASSWORD_BREACHED_FILE
+// ไฟล์รหัสผ่านที่หลุดเก็บรหัสผ่านไว้บรรทัดละหนึ่งรหัส
+func NewPasswordPolicy() (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{MinLength: IntEnv("PASSWORD_MIN_LENGTH", 8)}
+
+	path := os.Getenv("PASSWORD_BREACHED_FILE")
+	if path == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return policy, err
+	}
+	defer file.Close()
+
+	if err := policy.LoadBreached(file); err != nil {
+		return policy, err
+	}
+	return policy, nil
+}
+
+// เพิ่มรายการรหัสผ่านที่หลุด เทียบแบบไม่สนตัวพิมพ์เล็กใหญ่
+func (p *PasswordPolicy) LoadBreached(r io.Reader) error {
+	if p.breached == nil {
+		p.breached = make(map[string]struct{})
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// ตรวจรหัสผ่านตามเงื่อนไข คืน error ตัวแรกที่ไม่ผ่าน
+func (p *PasswordPolicy) Validate(password, username string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > maxPasswordLength {
+		return ErrPasswordTooLong
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return ErrPasswordSameUsername
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return ErrPasswordBreached
+	}
+	return nil
+}
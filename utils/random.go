@@ -1,6 +1,8 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
 	"math/rand"
 	"time"
 )
@@ -17,3 +19,12 @@ func GenerateRandomString(length int) string {
 	}
 	return string(randomString)
 }
+
+// สุ่มค่าสำหรับใช้เป็น token ที่ต้องเดาไม่ได้ คืนเป็น hex ยาว 2*size ตัวอักษร
+func GenerateToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := crand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// ลายเซ็น HMAC-SHA256 ของ webhook คำนวณจาก "timestamp.body" ในรูป sha256=<hex>
+// ผู้รับคำนวณซ้ำด้วย secret เดียวกันและตรวจ timestamp เพื่อกันการส่งซ้ำ
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
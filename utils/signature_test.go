@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"type":"post.created"}`)
+	assert.Equal(t, "sha256=940aae20b21e2ae05723769a4b6cbc0d698667f1a365172e808ba11f2a409b78", SignWebhook("secret", 1700000000, body))
+
+	// timestamp หรือ secret ต่างกันได้ลายเซ็นต่างกัน
+	assert.NotEqual(t, SignWebhook("secret", 1700000000, body), SignWebhook("secret", 1700000001, body))
+	assert.NotEqual(t, SignWebhook("secret", 1700000000, body), SignWebhook("other", 1700000000, body))
+}
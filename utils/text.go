@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// # หรือ @ ต้องอยู่ต้นข้อความหรือหลังตัวอักษรที่ไม่ใช่ส่วนหนึ่งของคำ เช่น email จะไม่ถูกนับเป็น mention
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]{1,100})`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.]{1,100})`)
+)
+
+// ดึง hashtag จากข้อความ คืนเป็นตัวพิมพ์เล็ก ไม่ซ้ำ และเรียงตามลำดับที่พบ
+func ExtractHashtags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToLower(match[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ดึง username ที่ถูก @mention จากข้อความ ไม่ซ้ำ และเรียงตามลำดับที่พบ
+func ExtractMentions(text string) []string {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		// จุดท้ายประโยคไม่ใช่ส่วนหนึ่งของ username
+		username := strings.TrimRight(match[1], ".")
+		key := strings.ToLower(username)
+		if username != "" && !seen[key] {
+			seen[key] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
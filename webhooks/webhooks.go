@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NopparootSuree/go-social/events"
+	"github.com/NopparootSuree/go-social/models"
+	"github.com/NopparootSuree/go-social/utils"
+	"gorm.io/gorm"
+)
+
+// ชนิด event ที่ส่งออกทาง webhook ได้
+var EventTypes = []string{
+	events.UserRegistered,
+	events.PostCreated,
+	events.PostDeleted,
+	events.FollowCreated,
+}
+
+// ส่งออกทาง webhook ได้หรือไม่
+func Supported(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload body ที่ส่งให้ปลายทาง ID ไม่เปลี่ยนเมื่อส่งซ้ำ ผู้รับใช้กันการประมวลผลซ้ำได้
+type Payload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      Data      `json:"data"`
+}
+
+type Data struct {
+	ActorID uint  `json:"actorID"`
+	UserID  uint  `json:"userID,omitempty"`
+	PostID  *uint `json:"postID,omitempty"`
+}
+
+// Enqueue ใช้ลงทะเบียนกับ events.Subscribe สร้างรายการส่งให้ทุก subscription ที่รับ event ชนิดนี้
+// รายการถูกบันทึกใน transaction เดียวกับ event จึงส่งเฉพาะเมื่อ commit สำเร็จ
+func Enqueue(tx *gorm.DB, event events.Event) error {
+	if !Supported(event.Type) {
+		return nil
+	}
+
+	var subscriptions []models.WebhookSubscriptions
+	if err := tx.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDeliveries
+	var body []byte
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(event.Type) {
+			continue
+		}
+		if body == nil {
+			id, err := utils.GenerateToken(16)
+			if err != nil {
+				return err
+			}
+			body, err = json.Marshal(Payload{
+				ID:        id,
+				Type:      event.Type,
+				CreatedAt: event.CreatedAt,
+				Data:      Data{ActorID: event.ActorID, UserID: event.UserID, PostID: event.PostID},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		deliveries = append(deliveries, models.WebhookDeliveries{
+			SubscriptionID: subscription.ID,
+			EventType:      event.Type,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  event.CreatedAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.Create(&deliveries).Error
+}